@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"venturo-core/configs"
 	"venturo-core/internal/database"
+	"venturo-core/internal/service"
 )
 
 func main() {
@@ -19,7 +21,7 @@ func main() {
 	database.ConnectDB(&config)
 
 	if len(os.Args) < 2 {
-		slog.Error("Please provide an argument: up, down, or fresh")
+		slog.Error("Please provide an argument: up, down, fresh, or backfill-slugs")
 		os.Exit(1)
 	}
 
@@ -33,6 +35,13 @@ func main() {
 	case "fresh":
 		database.Drop()
 		database.MigrateUp()
+	case "backfill-slugs":
+		count, err := service.BackfillSlugs(context.Background(), database.DB)
+		if err != nil {
+			slog.Error("Failed to backfill post slugs", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Backfilled post slugs", "count", count)
 	default:
 		slog.Error("Unknown command", "command", command)
 		os.Exit(1)
@@ -30,8 +30,8 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and a JWT.
 func main() {
-	// Get the app and the shared WaitGroup from our server setup
-	app, wg := server.NewServer()
+	// Get the app, the shared WaitGroup and the background job stopper from our server setup
+	app, wg, stopBackground := server.NewServer()
 	logger.InitLogger()
 
 	// Create a channel to listen for OS signals
@@ -49,5 +49,5 @@ func main() {
 	<-quit
 
 	// Trigger the graceful shutdown, passing the shared WaitGroup
-	server.GracefulShutdown(app, wg)
+	server.GracefulShutdown(app, wg, stopBackground)
 }
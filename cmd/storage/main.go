@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"venturo-core/configs"
+	"venturo-core/internal/database"
+	"venturo-core/internal/server"
+	"venturo-core/internal/service"
+)
+
+func main() {
+	slog.Info("Storage tool started")
+
+	config, err := configs.LoadConfig()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) < 2 {
+		slog.Error("Please provide an argument: gc")
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+
+	switch command {
+	case "gc":
+		collectOrphans(&config, os.Args[2:])
+	default:
+		slog.Error("Unknown command", "command", command)
+		os.Exit(1)
+	}
+}
+
+// collectOrphans deletes, or with -dry-run only reports, files nothing in the database refers to.
+func collectOrphans(config *configs.Config, args []string) {
+	flags := flag.NewFlagSet("gc", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report orphaned files without deleting them")
+	grace := flags.Duration("grace", config.OrphanGracePeriod, "only collect files older than this")
+	flags.Parse(args)
+
+	database.ConnectDB(config)
+
+	storageAdapter, err := server.NewStorageAdapter(config)
+	if err != nil {
+		slog.Error("Could not set up file storage", "driver", config.StorageDriver, "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collector := service.NewOrphanCollector(database.DB, config, storageAdapter)
+	report, err := collector.Collect(ctx, *grace, *dryRun)
+	if err != nil {
+		slog.Error("Failed to collect orphaned files", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Orphan collection finished", "dryRun", report.DryRun, "scanned", report.Scanned,
+		"orphans", len(report.Orphans), "bytes", report.Bytes, "deleted", report.Deleted)
+}
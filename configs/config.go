@@ -1,7 +1,14 @@
 package configs
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +22,55 @@ type Config struct {
 	DBName     string
 
 	JWTSecretKey string
+
+	AppURL    string
+	SiteTitle string
+
+	PostTrashRetention time.Duration
+	PostReactionTypes  []string
+
+	ReportAutoHideThreshold int
+
+	ViewDedupeWindow  time.Duration
+	ViewFlushInterval time.Duration
+
+	StorageDriver      string
+	StorageLocalRoot   string
+	StorageStagingRoot string
+	StoragePublicURL   string
+	StorageSignedURL   string
+	StorageSignKey     string
+
+	StorageS3Endpoint  string
+	StorageS3Region    string
+	StorageS3Bucket    string
+	StorageS3AccessKey string
+	StorageS3SecretKey string
+	StorageS3UseSSL    bool
+	StorageS3PathStyle bool
+
+	UploadMaxAttempts  int
+	UploadRetryDelay   time.Duration
+	UploadPollInterval time.Duration
+
+	ScannerDriver   string
+	ScannerOversize string
+	ClamAVAddress   string
+	ClamAVTimeout   time.Duration
+	ClamAVMaxSize   int64
+
+	AvatarMaxSize      int64
+	AvatarMaxDimension int
+
+	ResumableUploadMaxSize int64
+	ResumableUploadExpiry  time.Duration
+
+	UploadTicketMaxSize int64
+	UploadTicketExpiry  time.Duration
+
+	OrphanGracePeriod time.Duration
+	OrphanGCInterval  time.Duration
+	OrphanDryRun      bool
 }
 
 // LoadConfig loads application configuration from .env file
@@ -31,5 +87,132 @@ func LoadConfig() (config Config, err error) {
 	config.DBName = os.Getenv("DB_NAME")
 
 	config.JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
+
+	config.AppURL = strings.TrimRight(getEnv("APP_URL", "http://localhost:3000"), "/")
+	config.SiteTitle = getEnv("SITE_TITLE", "Venturo Core")
+
+	// Zero or less turns purging off, so trashed posts are kept until they are restored
+	config.PostTrashRetention = time.Duration(getEnvInt("POST_TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+	config.PostReactionTypes = getEnvList("POST_REACTION_TYPES", []string{"like", "love", "haha", "wow", "sad", "angry"})
+
+	config.ReportAutoHideThreshold = getEnvInt("REPORT_AUTO_HIDE_THRESHOLD", 5)
+
+	config.ViewDedupeWindow = time.Duration(getEnvInt("VIEW_DEDUPE_WINDOW_MINUTES", 30)) * time.Minute
+	config.ViewFlushInterval = time.Duration(getEnvInt("VIEW_FLUSH_INTERVAL_SECONDS", 10)) * time.Second
+	// Buffered views would pile up in memory if they were never flushed
+	if config.ViewFlushInterval <= 0 {
+		err = errors.New("VIEW_FLUSH_INTERVAL_SECONDS must be positive")
+		return
+	}
+
+	config.StorageDriver = getEnv("STORAGE_DRIVER", "local")
+	// The local root lives under ./public by default, so the /public static route serves it
+	config.StorageLocalRoot = getEnv("STORAGE_LOCAL_ROOT", "./public/storage")
+	// Uploads are staged outside ./public until they have been checked
+	config.StorageStagingRoot = getEnv("STORAGE_LOCAL_STAGING_ROOT", "./uploads/staging")
+	publicURL := config.AppURL + "/public/storage"
+	if config.StorageDriver == "s3" {
+		publicURL = "" // The adapter falls back to the bucket's own URL
+	}
+	config.StoragePublicURL = strings.TrimRight(getEnv("STORAGE_PUBLIC_URL", publicURL), "/")
+	// Signed links to local files are served by the API's /files route
+	config.StorageSignedURL = config.AppURL + "/api/v1/files"
+	config.StorageSignKey = os.Getenv("STORAGE_SIGNING_KEY")
+	if config.StorageSignKey == "" {
+		// Never sign links with the JWT secret itself, so one leaked key cannot forge the other's signatures
+		config.StorageSignKey = deriveKey(config.JWTSecretKey, "venturo-core storage signing key")
+	}
+
+	config.StorageS3Endpoint = getEnv("STORAGE_S3_ENDPOINT", "s3.amazonaws.com")
+	config.StorageS3Region = os.Getenv("STORAGE_S3_REGION")
+	config.StorageS3Bucket = os.Getenv("STORAGE_S3_BUCKET")
+	config.StorageS3AccessKey = os.Getenv("STORAGE_S3_ACCESS_KEY")
+	config.StorageS3SecretKey = os.Getenv("STORAGE_S3_SECRET_KEY")
+	config.StorageS3UseSSL = getEnvBool("STORAGE_S3_USE_SSL", true)
+	config.StorageS3PathStyle = getEnvBool("STORAGE_S3_PATH_STYLE", false)
+
+	config.UploadMaxAttempts = getEnvInt("UPLOAD_MAX_ATTEMPTS", 8)
+	config.UploadRetryDelay = time.Duration(getEnvInt("UPLOAD_RETRY_DELAY_SECONDS", 10)) * time.Second
+	config.UploadPollInterval = time.Duration(getEnvInt("UPLOAD_POLL_INTERVAL_SECONDS", 2)) * time.Second
+	// Without polling, queued uploads would never leave the server
+	if config.UploadPollInterval <= 0 {
+		err = errors.New("UPLOAD_POLL_INTERVAL_SECONDS must be positive")
+		return
+	}
+
+	config.ScannerDriver = getEnv("SCANNER_DRIVER", "none")
+	config.ClamAVAddress = getEnv("CLAMAV_ADDRESS", "127.0.0.1:3310")
+	config.ClamAVTimeout = time.Duration(getEnvInt("CLAMAV_TIMEOUT_SECONDS", 60)) * time.Second
+	// Must not exceed StreamMaxLength in clamd.conf, which defaults to 25 MB
+	config.ClamAVMaxSize = int64(getEnvInt("CLAMAV_MAX_SIZE_MB", 25)) * 1024 * 1024
+	config.ScannerOversize = getEnv("SCANNER_OVERSIZE_POLICY", "refuse")
+
+	// Keep the avatar limit below Fiber's 4 MB request body limit
+	config.AvatarMaxSize = int64(getEnvInt("AVATAR_MAX_SIZE_KB", 2048)) * 1024
+	config.AvatarMaxDimension = getEnvInt("AVATAR_MAX_DIMENSION", 4096)
+
+	config.ResumableUploadMaxSize = int64(getEnvInt("RESUMABLE_UPLOAD_MAX_SIZE_MB", 1024)) * 1024 * 1024
+	config.ResumableUploadExpiry = time.Duration(getEnvInt("RESUMABLE_UPLOAD_EXPIRY_HOURS", 24)) * time.Hour
+
+	config.UploadTicketMaxSize = int64(getEnvInt("UPLOAD_TICKET_MAX_SIZE_MB", 1024)) * 1024 * 1024
+	config.UploadTicketExpiry = time.Duration(getEnvInt("UPLOAD_TICKET_EXPIRY_MINUTES", 60)) * time.Minute
+
+	config.OrphanGracePeriod = time.Duration(getEnvInt("ORPHAN_GC_GRACE_HOURS", 24)) * time.Hour
+	// Zero or less turns the background collection off; it can still be run on demand
+	config.OrphanGCInterval = time.Duration(getEnvInt("ORPHAN_GC_INTERVAL_HOURS", 24)) * time.Hour
+	config.OrphanDryRun = getEnvBool("ORPHAN_GC_DRY_RUN", false)
 	return
 }
+
+// deriveKey derives a separate secret for the purpose named by label from a master secret,
+// using HKDF-SHA256. An empty master secret derives nothing.
+func deriveKey(secret, label string) string {
+	if secret == "" {
+		return ""
+	}
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, label, 32)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(key)
+}
+
+// getEnv reads an environment variable, falling back to a default when unset.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt reads an integer environment variable, falling back to a default when unset or invalid.
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool reads a boolean environment variable, falling back to a default when unset or invalid.
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList reads a comma-separated environment variable, falling back to a default when unset.
+func getEnvList(key string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
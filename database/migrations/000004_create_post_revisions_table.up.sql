@@ -0,0 +1,12 @@
+CREATE TABLE post_revisions (
+    id CHAR(36) PRIMARY KEY,
+    post_id CHAR(36) NOT NULL,
+    revision INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    editor_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_post_revisions_post_revision (post_id, revision),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (editor_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE post_reactions (
+    post_id CHAR(36) NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id, type),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
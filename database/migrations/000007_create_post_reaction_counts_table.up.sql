@@ -0,0 +1,7 @@
+CREATE TABLE post_reaction_counts (
+    post_id CHAR(36) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, type),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+DROP INDEX `idx_posts_reactions_count`,
+DROP COLUMN `reactions_count`;
\ No newline at end of file
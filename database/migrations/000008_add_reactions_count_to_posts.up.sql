@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+ADD COLUMN `reactions_count` INT NOT NULL DEFAULT 0 AFTER `user_id`,
+ADD INDEX `idx_posts_reactions_count` (`reactions_count`);
\ No newline at end of file
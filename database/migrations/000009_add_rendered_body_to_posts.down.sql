@@ -0,0 +1,4 @@
+ALTER TABLE `posts`
+DROP COLUMN `body_html`,
+DROP COLUMN `excerpt`,
+DROP COLUMN `reading_time`;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE `posts`
+ADD COLUMN `body_html` MEDIUMTEXT NULL AFTER `body`,
+ADD COLUMN `excerpt` VARCHAR(300) NOT NULL DEFAULT '' AFTER `body_html`,
+ADD COLUMN `reading_time` INT NOT NULL DEFAULT 0 AFTER `excerpt`;
\ No newline at end of file
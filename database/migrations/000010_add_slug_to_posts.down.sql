@@ -0,0 +1,2 @@
+ALTER TABLE `posts`
+DROP COLUMN `slug`;
\ No newline at end of file
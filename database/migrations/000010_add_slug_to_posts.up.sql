@@ -0,0 +1,2 @@
+ALTER TABLE `posts`
+ADD COLUMN `slug` VARCHAR(255) NULL DEFAULT NULL AFTER `title`;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE post_slugs (
+    slug VARCHAR(255) PRIMARY KEY,
+    post_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_post_slugs_post_id (post_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
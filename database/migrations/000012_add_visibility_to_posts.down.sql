@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+DROP INDEX `idx_posts_visibility`,
+DROP COLUMN `visibility`;
\ No newline at end of file
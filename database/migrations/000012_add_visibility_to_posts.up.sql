@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+ADD COLUMN `visibility` VARCHAR(20) NOT NULL DEFAULT 'public' AFTER `body`,
+ADD INDEX `idx_posts_visibility` (`visibility`);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_attachments;
\ No newline at end of file
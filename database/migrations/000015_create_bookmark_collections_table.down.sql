@@ -0,0 +1 @@
+DROP TABLE IF EXISTS bookmark_collections;
\ No newline at end of file
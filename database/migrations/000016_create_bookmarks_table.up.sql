@@ -0,0 +1,11 @@
+CREATE TABLE bookmarks (
+    user_id CHAR(36) NOT NULL,
+    post_id CHAR(36) NOT NULL,
+    collection_id CHAR(36) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    INDEX idx_bookmarks_user_created (user_id, created_at, post_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE SET NULL
+);
\ No newline at end of file
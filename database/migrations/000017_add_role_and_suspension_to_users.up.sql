@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+ADD COLUMN `role` VARCHAR(20) NOT NULL DEFAULT 'user' AFTER `password`,
+ADD COLUMN `suspended_at` TIMESTAMP NULL DEFAULT NULL AFTER `role`;
\ No newline at end of file
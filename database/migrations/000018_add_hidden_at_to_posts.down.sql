@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+DROP INDEX `idx_posts_hidden_at`,
+DROP COLUMN `hidden_at`;
\ No newline at end of file
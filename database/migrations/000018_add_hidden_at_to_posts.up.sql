@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+ADD COLUMN `hidden_at` TIMESTAMP NULL DEFAULT NULL AFTER `visibility`,
+ADD INDEX `idx_posts_hidden_at` (`hidden_at`);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_reports;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE post_reports (
+    id CHAR(36) PRIMARY KEY,
+    post_id CHAR(36) NOT NULL,
+    reporter_id CHAR(36) NOT NULL,
+    reason VARCHAR(32) NOT NULL,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP NULL DEFAULT NULL,
+    UNIQUE KEY uq_post_reports_post_reporter (post_id, reporter_id),
+    INDEX idx_post_reports_status_post (status, post_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+DROP INDEX `uq_users_username`,
+DROP COLUMN `username`;
\ No newline at end of file
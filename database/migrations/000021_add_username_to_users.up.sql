@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+ADD COLUMN `username` VARCHAR(30) NULL DEFAULT NULL AFTER `name`,
+ADD UNIQUE KEY `uq_users_username` (`username`);
\ No newline at end of file
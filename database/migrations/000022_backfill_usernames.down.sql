@@ -0,0 +1 @@
+UPDATE users SET username = NULL WHERE username = CONCAT('user_', LEFT(REPLACE(id, '-', ''), 12));
\ No newline at end of file
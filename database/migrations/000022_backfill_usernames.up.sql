@@ -0,0 +1 @@
+UPDATE users SET username = CONCAT('user_', LEFT(REPLACE(id, '-', ''), 12)) WHERE username IS NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_mentions;
\ No newline at end of file
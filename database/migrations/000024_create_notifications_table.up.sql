@@ -0,0 +1,15 @@
+CREATE TABLE notifications (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    actor_id CHAR(36) NULL,
+    type VARCHAR(32) NOT NULL,
+    target_type VARCHAR(32) NOT NULL,
+    target_id CHAR(36) NOT NULL,
+    dedupe_key VARCHAR(191) NULL DEFAULT NULL,
+    read_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_notifications_dedupe_key (dedupe_key),
+    INDEX idx_notifications_user_created (user_id, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+);
\ No newline at end of file
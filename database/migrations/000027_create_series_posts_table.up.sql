@@ -0,0 +1,9 @@
+CREATE TABLE series_posts (
+    post_id CHAR(36) PRIMARY KEY,
+    series_id CHAR(36) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_series_posts_series_position (series_id, position),
+    FOREIGN KEY (series_id) REFERENCES series(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
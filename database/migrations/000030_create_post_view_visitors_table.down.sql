@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_view_visitors;
\ No newline at end of file
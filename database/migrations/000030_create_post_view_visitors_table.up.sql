@@ -0,0 +1,7 @@
+CREATE TABLE post_view_visitors (
+    post_id CHAR(36) NOT NULL,
+    day DATE NOT NULL,
+    visitor CHAR(64) NOT NULL,
+    PRIMARY KEY (post_id, day, visitor),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS post_view_referrers;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE post_view_referrers (
+    post_id CHAR(36) NOT NULL,
+    day DATE NOT NULL,
+    referrer VARCHAR(255) NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, day, referrer),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS upload_jobs;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE upload_jobs (
+    id CHAR(36) PRIMARY KEY,
+    target_type VARCHAR(20) NOT NULL,
+    target_id CHAR(36) NOT NULL,
+    object_name VARCHAR(255) NOT NULL,
+    local_path VARCHAR(500) NOT NULL,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(1000) NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_upload_jobs_status_next_attempt (status, next_attempt_at),
+    INDEX idx_upload_jobs_target (target_type, target_id)
+);
\ No newline at end of file
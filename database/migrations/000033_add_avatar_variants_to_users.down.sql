@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+DROP COLUMN `avatar_variants`;
\ No newline at end of file
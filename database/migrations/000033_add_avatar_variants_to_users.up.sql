@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+ADD COLUMN `avatar_variants` VARCHAR(50) NOT NULL DEFAULT '' AFTER `avatar_url`;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS stored_files;
\ No newline at end of file
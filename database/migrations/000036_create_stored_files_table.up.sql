@@ -0,0 +1,15 @@
+CREATE TABLE stored_files (
+    id CHAR(36) PRIMARY KEY,
+    sha256 CHAR(64) NOT NULL,
+    object_name VARCHAR(255) NOT NULL,
+    variants VARCHAR(50) NOT NULL DEFAULT '',
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    ref_count INT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'local',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_stored_files_sha256 (sha256),
+    UNIQUE KEY uq_stored_files_object_name (object_name),
+    INDEX idx_stored_files_ref_count_updated_at (ref_count, updated_at)
+);
\ No newline at end of file
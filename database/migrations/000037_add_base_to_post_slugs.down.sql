@@ -0,0 +1,2 @@
+ALTER TABLE `post_slugs`
+DROP COLUMN `base`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `post_slugs`
+ADD COLUMN `base` VARCHAR(255) NOT NULL DEFAULT '' AFTER `post_id`;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE post_tags (
+    post_id CHAR(36) NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, tag),
+    INDEX idx_post_tags_tag (tag),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `upload_jobs`
+DROP COLUMN `staged`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `upload_jobs`
+ADD COLUMN `staged` BOOLEAN NOT NULL DEFAULT FALSE AFTER `local_path`;
\ No newline at end of file
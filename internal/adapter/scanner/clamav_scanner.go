@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamdChunkSize is the size of the chunks content is streamed to clamd in.
+const clamdChunkSize = 64 * 1024
+
+// errClamdReply marks errors clamd itself replied with.
+var errClamdReply = errors.New("clamd")
+
+// What a ClamAVScanner does with content over its size limit.
+const (
+	// OversizeRefuse fails the scan with ErrTooLarge, unless the part scanned is infected.
+	OversizeRefuse = "refuse"
+	// OversizeSkip scans the start of the content and skips the rest, reporting a partial scan.
+	OversizeSkip = "skip"
+)
+
+// ClamAVOptions configures a ClamAVScanner.
+type ClamAVOptions struct {
+	Address  string        // host:port of clamd's TCP socket, or the path of its Unix socket
+	Timeout  time.Duration // Longest a single scan may take, 0 for no limit
+	MaxSize  int64         // Most bytes sent to clamd, at most its StreamMaxLength; 0 for no limit
+	Oversize string        // What to do with content over MaxSize: OversizeRefuse or OversizeSkip
+}
+
+// ClamAVScanner scans content with a clamd daemon, streaming it over clamd's socket with the
+// INSTREAM command. A new connection is made for every scan, so a restarted daemon is picked
+// up without reconnecting. clamd drops streams over its StreamMaxLength (25 MB by default) with
+// an error, so no more than MaxSize bytes are sent; larger content is handled as Oversize says.
+type ClamAVScanner struct {
+	network  string
+	address  string
+	timeout  time.Duration
+	maxSize  int64
+	oversize string
+}
+
+// NewClamAVScanner creates a scanner talking to the clamd daemon at the configured address.
+func NewClamAVScanner(opts ClamAVOptions) (*ClamAVScanner, error) {
+	if opts.Address == "" {
+		return nil, errors.New("no clamd address configured")
+	}
+	if opts.MaxSize > 0 && opts.Oversize != OversizeRefuse && opts.Oversize != OversizeSkip {
+		return nil, fmt.Errorf("unknown oversize policy %q", opts.Oversize)
+	}
+	network := "tcp"
+	if strings.HasPrefix(opts.Address, "/") {
+		network = "unix"
+	}
+	return &ClamAVScanner{
+		network:  network,
+		address:  opts.Address,
+		timeout:  opts.Timeout,
+		maxSize:  opts.MaxSize,
+		oversize: opts.Oversize,
+	}, nil
+}
+
+// Scan streams the content to clamd and reads back its verdict. Only the first MaxSize bytes are
+// sent; if there are more, the scan is refused or reported as partial, unless they are infected.
+func (s *ClamAVScanner) Scan(ctx context.Context, content io.Reader) (*Result, error) {
+	if s.maxSize <= 0 {
+		return s.scan(ctx, content)
+	}
+
+	head := &io.LimitedReader{R: content, N: s.maxSize}
+	result, err := s.scan(ctx, head)
+	if err != nil || result.Infected || head.N > 0 {
+		return result, err
+	}
+	if n, _ := io.ReadFull(content, make([]byte, 1)); n == 0 {
+		return result, nil // Exactly MaxSize bytes long
+	}
+	if s.oversize == OversizeRefuse {
+		return nil, fmt.Errorf("%w: larger than %d bytes", ErrTooLarge, s.maxSize)
+	}
+	result.Partial = true
+	return result, nil
+}
+
+// scan streams all of the content to clamd and reads back its verdict.
+func (s *ClamAVScanner) scan(ctx context.Context, content io.Reader) (*Result, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes once the scan is cancelled or times out
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := stream(conn, content); err != nil {
+		// clamd replies with the reason before closing the connection when it refuses a
+		// stream, for example one over its size limit
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			if _, replyErr := readReply(conn); errors.Is(replyErr, errClamdReply) {
+				return nil, replyErr
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("clamd: %w", ctx.Err())
+		}
+		return nil, err
+	}
+
+	result, err := readReply(conn)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("clamd: %w", ctx.Err())
+	}
+	return result, err
+}
+
+// stream sends the INSTREAM command followed by the content, in chunks each prefixed with its
+// length as a 4-byte big-endian integer. A zero-length chunk marks the end of the content.
+func stream(conn net.Conn, content io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := content.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading content to scan: %w", err)
+		}
+	}
+
+	if _, err := conn.Write(make([]byte, 4)); err != nil {
+		return fmt.Errorf("clamd: %w", err)
+	}
+	return nil
+}
+
+// readReply reads clamd's null-terminated reply to a scan: "stream: OK" for clean content,
+// "stream: <signature> FOUND" for infected content, or a message ending in "ERROR".
+func readReply(conn net.Conn) (*Result, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && (!errors.Is(err, io.EOF) || reply == "") {
+		return nil, fmt.Errorf("clamd: %w", err)
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	switch {
+	case strings.HasSuffix(reply, " OK"):
+		return &Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return &Result{Infected: true, Signature: signature}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errClamdReply, reply)
+}
@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubClamd answers INSTREAM scans on a local TCP socket like clamd does, replying with what
+// reply returns for the content received. Streams over limit bytes are dropped with clamd's
+// size limit error, unless limit is 0.
+func stubClamd(t *testing.T, limit int, reply func(content []byte) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, limit, reply)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveClamd(conn net.Conn, limit int, reply func(content []byte) string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	if command, err := r.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+		io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var content []byte
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return
+		}
+		content = append(content, chunk...)
+		if limit > 0 && len(content) > limit {
+			io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+	}
+	io.WriteString(conn, reply(content)+"\x00")
+}
+
+// eicarReply flags content holding "EICAR" and passes anything else.
+func eicarReply(content []byte) string {
+	if bytes.Contains(content, []byte("EICAR")) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+	return "stream: OK"
+}
+
+func newTestScanner(t *testing.T, opts ClamAVOptions) *ClamAVScanner {
+	t.Helper()
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	s, err := NewClamAVScanner(opts)
+	if err != nil {
+		t.Fatalf("NewClamAVScanner: %v", err)
+	}
+	return s
+}
+
+func TestClamAVScannerReplies(t *testing.T) {
+	address := stubClamd(t, 0, eicarReply)
+	s := newTestScanner(t, ClamAVOptions{Address: address})
+	ctx := context.Background()
+
+	// Large enough to be sent in several chunks
+	clean := bytes.Repeat([]byte("clean "), 50_000)
+	result, err := s.Scan(ctx, bytes.NewReader(clean))
+	if err != nil || result.Infected || result.Partial {
+		t.Errorf("Scan of clean content = %+v, %v; want clean", result, err)
+	}
+
+	result, err = s.Scan(ctx, strings.NewReader("X5O!P%@AP EICAR test file"))
+	if err != nil || !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("Scan of infected content = %+v, %v; want Eicar-Test-Signature", result, err)
+	}
+}
+
+func TestClamAVScannerErrorReply(t *testing.T) {
+	address := stubClamd(t, 0, func([]byte) string {
+		return "Can't allocate memory ERROR"
+	})
+	s := newTestScanner(t, ClamAVOptions{Address: address})
+
+	result, err := s.Scan(context.Background(), strings.NewReader("content"))
+	if !errors.Is(err, errClamdReply) || !strings.Contains(err.Error(), "Can't allocate memory") {
+		t.Errorf("Scan = %+v, %v; want clamd's error", result, err)
+	}
+}
+
+func TestClamAVScannerSizeLimitReply(t *testing.T) {
+	address := stubClamd(t, 1024, eicarReply)
+	s := newTestScanner(t, ClamAVOptions{Address: address})
+
+	// Without MaxSize, content over clamd's limit is sent and clamd drops it
+	result, err := s.Scan(context.Background(), bytes.NewReader(make([]byte, 4*clamdChunkSize)))
+	if !errors.Is(err, errClamdReply) || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Errorf("Scan = %+v, %v; want clamd's size limit error", result, err)
+	}
+}
+
+func TestClamAVScannerOversize(t *testing.T) {
+	const limit = 1024
+	address := stubClamd(t, limit, eicarReply)
+	ctx := context.Background()
+	refuse := newTestScanner(t, ClamAVOptions{Address: address, MaxSize: limit, Oversize: OversizeRefuse})
+	skip := newTestScanner(t, ClamAVOptions{Address: address, MaxSize: limit, Oversize: OversizeSkip})
+
+	for _, s := range []*ClamAVScanner{refuse, skip} {
+		result, err := s.Scan(ctx, bytes.NewReader(make([]byte, limit)))
+		if err != nil || result.Infected || result.Partial {
+			t.Errorf("%s: Scan of content at the limit = %+v, %v; want a full clean scan", s.oversize, result, err)
+		}
+
+		infected := append([]byte("EICAR"), make([]byte, 2*limit)...)
+		result, err = s.Scan(ctx, bytes.NewReader(infected))
+		if err != nil || !result.Infected {
+			t.Errorf("%s: Scan of oversized infected content = %+v, %v; want infected", s.oversize, result, err)
+		}
+	}
+
+	result, err := refuse.Scan(ctx, bytes.NewReader(make([]byte, limit+1)))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("refuse: Scan of oversized content = %+v, %v; want ErrTooLarge", result, err)
+	}
+
+	result, err = skip.Scan(ctx, bytes.NewReader(make([]byte, limit+1)))
+	if err != nil || result.Infected || !result.Partial {
+		t.Errorf("skip: Scan of oversized content = %+v, %v; want a partial clean scan", result, err)
+	}
+}
+
+func TestClamAVScannerUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	s := newTestScanner(t, ClamAVOptions{Address: address})
+	if result, err := s.Scan(context.Background(), strings.NewReader("content")); err == nil {
+		t.Errorf("Scan with clamd down = %+v; want an error", result)
+	}
+}
+
+func TestClamAVScannerTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		// Accept the scan but never reply
+		conn, err := listener.Accept()
+		if err == nil {
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	s := newTestScanner(t, ClamAVOptions{Address: listener.Addr().String(), Timeout: 200 * time.Millisecond})
+	start := time.Now()
+	result, err := s.Scan(context.Background(), strings.NewReader("content"))
+	if err == nil {
+		t.Errorf("Scan without a reply = %+v; want an error", result)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Scan gave up after %v, want about the 200ms timeout", elapsed)
+	}
+}
+
+func TestNewClamAVScannerOptions(t *testing.T) {
+	if _, err := NewClamAVScanner(ClamAVOptions{}); err == nil {
+		t.Error("NewClamAVScanner succeeded without an address")
+	}
+	if _, err := NewClamAVScanner(ClamAVOptions{Address: "127.0.0.1:3310", MaxSize: 1, Oversize: "ignore"}); err == nil {
+		t.Error("NewClamAVScanner succeeded with an unknown oversize policy")
+	}
+	s, err := NewClamAVScanner(ClamAVOptions{Address: "/run/clamav/clamd.ctl"})
+	if err != nil || s.network != "unix" {
+		t.Errorf("NewClamAVScanner with a socket path = %+v, %v; want a Unix socket", s, err)
+	}
+}
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrInvalidObjectName is returned for object names that are not a single plain path element.
+var ErrInvalidObjectName = errors.New("invalid object name")
+
+// ErrInvalidSignature is returned for signed links that were tampered with or have expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// tempPrefix marks files that are still being written.
+const tempPrefix = ".upload-"
+
+// uploadSignaturePrefix sets the signatures of upload links apart from those of download links.
+// Object names cannot contain a slash, so no key can produce the same signed message.
+const uploadSignaturePrefix = "PUT/"
+
+// rename moves files for Move. Tests replace it to move objects across filesystems.
+var rename = os.Rename
+
+// LocalFSOptions configures a LocalFSAdapter.
+type LocalFSOptions struct {
+	Root        string // Directory objects are stored in
+	StagingRoot string // Directory staged objects are kept in, which must not be served
+	PublicURL   string // Base URL the root directory is served from
+	SignedURL   string // Base URL of the route serving signed links
+	SigningKey  string // Secret signed links are signed with
+}
+
+// LocalFSAdapter stores objects on the local filesystem. Objects are spread over a
+// two-level directory tree derived from a hash of their name (ab/cd/name), so no
+// single directory grows too large. Serving the root directory, for example through
+// the /public static route, makes the objects reachable at the public URL. Staged
+// objects are kept in a flat directory of their own, away from the served root.
+type LocalFSAdapter struct {
+	root        string
+	stagingRoot string
+	publicURL   string
+	signedURL   string
+	signingKey  []byte
+}
+
+// NewLocalFSAdapter creates an adapter storing objects under the configured root.
+func NewLocalFSAdapter(opts LocalFSOptions) (*LocalFSAdapter, error) {
+	if opts.StagingRoot == "" {
+		return nil, errors.New("no staging directory configured")
+	}
+	for _, dir := range []string{opts.Root, opts.StagingRoot} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &LocalFSAdapter{
+		root:        opts.Root,
+		stagingRoot: opts.StagingRoot,
+		publicURL:   strings.TrimRight(opts.PublicURL, "/"),
+		signedURL:   strings.TrimRight(opts.SignedURL, "/"),
+		signingKey:  []byte(opts.SigningKey),
+	}, nil
+}
+
+// Put writes the object atomically: the content goes to a temp file in the target
+// directory first, which is renamed into place only once it is completely on disk.
+// Readers therefore never see a partially written object. The content type is not
+// stored; it is derived from the key's extension when the object is read.
+func (a *LocalFSAdapter) Put(ctx context.Context, key string, content io.Reader, meta ObjectMeta) error {
+	path, err := a.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	// Removing the temp file fails harmlessly once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, &contextReader{ctx: ctx, r: content}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the object for reading.
+func (a *LocalFSAdapter) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	path, err := a.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, objectInfo(key, stat), nil
+}
+
+// Delete removes the object. Deleting an object that does not exist is a no-op.
+func (a *LocalFSAdapter) Delete(ctx context.Context, key string) error {
+	path, err := a.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Move renames the object's file. Staged objects are kept outside the root, so when the two are
+// on different filesystems the object is copied over instead.
+func (a *LocalFSAdapter) Move(ctx context.Context, src, dst string) error {
+	srcPath, err := a.path(src)
+	if err != nil {
+		return err
+	}
+	dstPath, err := a.path(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0o755); err != nil {
+		return err
+	}
+
+	err = rename(srcPath, dstPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrObjectNotFound
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	content, _, err := a.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if err := a.Put(ctx, dst, content, ObjectMeta{}); err != nil {
+		return err
+	}
+	return a.Delete(ctx, src)
+}
+
+// Stat describes the object without opening it.
+func (a *LocalFSAdapter) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	path, err := a.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objectInfo(key, stat), nil
+}
+
+// List walks the whole tree, since objects sharing a prefix are spread over many directories.
+func (a *LocalFSAdapter) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.WalkDir(a.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		// Only files at their sharded location are objects; this also skips temp files
+		if objectPath, err := a.path(name); err != nil || objectPath != path {
+			return nil
+		}
+
+		stat, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // Deleted while walking
+		}
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *objectInfo(name, stat))
+		return nil
+	})
+	return objects, err
+}
+
+// URL returns the address of the object below the public URL. Staged objects have none.
+func (a *LocalFSAdapter) URL(key string) string {
+	path, err := a.path(key)
+	if err != nil || strings.HasPrefix(key, stagingPrefix) {
+		return ""
+	}
+	rel, _ := filepath.Rel(a.root, path)
+	return a.publicURL + "/" + filepath.ToSlash(rel)
+}
+
+// SignedURL returns a link to the signed-link route, carrying an expiry and an HMAC of
+// the key and expiry. The route checks it with VerifySignature before serving the object.
+func (a *LocalFSAdapter) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := a.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {a.sign(key, expires)}}
+	return a.signedURL + "/" + url.PathEscape(key) + "?" + query.Encode(), nil
+}
+
+// SignedUploadURL returns a link to the signed-link route that accepts the object's content
+// with PUT. Its signature also covers the method, so a download link cannot be used to upload.
+func (a *LocalFSAdapter) SignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := a.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {a.sign(uploadSignaturePrefix+key, expires)}}
+	return a.signedURL + "/" + url.PathEscape(key) + "?" + query.Encode(), nil
+}
+
+// VerifySignature checks the expiry and signature of a link made by SignedURL.
+func (a *LocalFSAdapter) VerifySignature(key, expires, signature string) error {
+	return a.verify(key, expires, signature)
+}
+
+// VerifyUploadSignature checks the expiry and signature of a link made by SignedUploadURL.
+func (a *LocalFSAdapter) VerifyUploadSignature(key, expires, signature string) error {
+	return a.verify(uploadSignaturePrefix+key, expires, signature)
+}
+
+// verify checks that a link has not expired and that its signature matches.
+func (a *LocalFSAdapter) verify(message, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(a.sign(message, expires))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// sign computes the signature of a link to key that expires at the given Unix time.
+func (a *LocalFSAdapter) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, a.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps a key to its file, spread over two levels of directories named after
+// a hash of the key. Staged objects are few and short-lived, so they are not spread out.
+func (a *LocalFSAdapter) path(key string) (string, error) {
+	if !validObjectName(key) || strings.HasPrefix(key, tempPrefix) {
+		return "", ErrInvalidObjectName
+	}
+	if strings.HasPrefix(key, stagingPrefix) {
+		return filepath.Join(a.stagingRoot, key), nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	shard := hex.EncodeToString(sum[:2])
+	return filepath.Join(a.root, shard[:2], shard[2:], key), nil
+}
+
+// validObjectName reports whether an object name is a single, plain path element.
+func validObjectName(objectName string) bool {
+	return objectName != "" && objectName != "." && objectName != ".." &&
+		!strings.ContainsAny(objectName, `/\`)
+}
+
+// objectInfo describes a stored file.
+func objectInfo(key string, stat fs.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  contentType(key),
+		LastModified: stat.ModTime(),
+	}
+}
+
+// contentType guesses the media type of an object from its key's extension.
+func contentType(key string) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// contextReader stops a copy once its context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
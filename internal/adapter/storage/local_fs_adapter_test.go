@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestLocalFSAdapter(t *testing.T) *LocalFSAdapter {
+	t.Helper()
+	dir := t.TempDir()
+	adapter, err := NewLocalFSAdapter(LocalFSOptions{
+		Root:        filepath.Join(dir, "public"),
+		StagingRoot: filepath.Join(dir, "staging"),
+		PublicURL:   "http://localhost:3000/public/storage/",
+		SignedURL:   "http://localhost:3000/api/v1/files/",
+		SigningKey:  "test signing key",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalFSAdapter: %v", err)
+	}
+	return adapter
+}
+
+// assertNoTempFiles checks that no temp file of an unfinished Put is left under dir.
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && strings.HasPrefix(entry.Name(), tempPrefix) {
+			t.Errorf("temp file %s left behind", path)
+		}
+		return nil
+	})
+}
+
+func TestLocalFSAdapterPutGetDelete(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+	content := []byte("hello from the disk")
+
+	if err := adapter.Put(ctx, "hello.txt", bytes.NewReader(content), ObjectMeta{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	path, _ := adapter.path("hello.txt")
+	rel, _ := filepath.Rel(adapter.root, path)
+	if parts := strings.Split(filepath.ToSlash(rel), "/"); len(parts) != 3 || len(parts[0]) != 2 || len(parts[1]) != 2 || parts[2] != "hello.txt" {
+		t.Errorf("object stored at %q, want ab/cd/hello.txt", rel)
+	}
+	if stored, err := os.ReadFile(path); err != nil || !bytes.Equal(stored, content) {
+		t.Fatalf("stored %q, %v; want %q", stored, err, content)
+	}
+	assertNoTempFiles(t, adapter.root)
+
+	reader, info, err := adapter.Get(ctx, "hello.txt")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("Get read %q, %v; want %q", got, err, content)
+	}
+	if info.Key != "hello.txt" || info.Size != int64(len(content)) || info.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("Get info = %+v", info)
+	}
+
+	objects, err := adapter.List(ctx, "hello")
+	if err != nil || len(objects) != 1 || objects[0].Key != "hello.txt" {
+		t.Fatalf("List = %+v, %v", objects, err)
+	}
+
+	if err := adapter.Delete(ctx, "hello.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := adapter.Stat(ctx, "hello.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Stat after Delete = %v, want ErrObjectNotFound", err)
+	}
+	if _, _, err := adapter.Get(ctx, "hello.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrObjectNotFound", err)
+	}
+	if err := adapter.Delete(ctx, "hello.txt"); err != nil {
+		t.Errorf("Delete of a missing object = %v, want nil", err)
+	}
+}
+
+// failingReader returns some content, then an error.
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.sent {
+		return 0, errors.New("connection reset")
+	}
+	r.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestLocalFSAdapterPutIsAtomic(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+
+	if err := adapter.Put(ctx, "kept.txt", strings.NewReader("original"), ObjectMeta{}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := adapter.Put(ctx, "kept.txt", &failingReader{}, ObjectMeta{}); err == nil {
+		t.Fatal("Put of failing content succeeded")
+	}
+
+	reader, _, err := adapter.Get(ctx, "kept.txt")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(got) != "original" {
+		t.Errorf("failed Put left %q, want the original object", got)
+	}
+	assertNoTempFiles(t, adapter.root)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := adapter.Put(cancelled, "new.txt", strings.NewReader("content"), ObjectMeta{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Put with a cancelled context = %v, want context.Canceled", err)
+	}
+	if _, err := adapter.Stat(ctx, "new.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Stat after a cancelled Put = %v, want ErrObjectNotFound", err)
+	}
+	assertNoTempFiles(t, adapter.root)
+}
+
+func TestLocalFSAdapterStaging(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+
+	for _, key := range []string{StagingKey("staged.txt"), HeldKey("staged.txt")} {
+		if err := adapter.Put(ctx, key, strings.NewReader("unchecked"), ObjectMeta{}); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+		if _, err := os.Stat(filepath.Join(adapter.stagingRoot, key)); err != nil {
+			t.Errorf("%s not kept flat in the staging directory: %v", key, err)
+		}
+		if url := adapter.URL(key); url != "" {
+			t.Errorf("URL(%s) = %q, want none", key, url)
+		}
+	}
+
+	objects, err := adapter.List(ctx, "")
+	if err != nil || len(objects) != 0 {
+		t.Errorf("List = %+v, %v; want staged objects left out", objects, err)
+	}
+}
+
+func TestLocalFSAdapterMove(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+
+	move := func(t *testing.T, name string) {
+		t.Helper()
+		staged := StagingKey(name)
+		if err := adapter.Put(ctx, staged, strings.NewReader("checked"), ObjectMeta{}); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if err := adapter.Move(ctx, staged, name); err != nil {
+			t.Fatalf("Move: %v", err)
+		}
+		if _, err := adapter.Stat(ctx, staged); !errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("Stat of the source after Move = %v, want ErrObjectNotFound", err)
+		}
+		reader, _, err := adapter.Get(ctx, name)
+		if err != nil {
+			t.Fatalf("Get after Move: %v", err)
+		}
+		got, _ := io.ReadAll(reader)
+		reader.Close()
+		if string(got) != "checked" {
+			t.Errorf("moved object holds %q, want %q", got, "checked")
+		}
+	}
+
+	t.Run("rename", func(t *testing.T) {
+		move(t, "renamed.txt")
+	})
+
+	t.Run("across filesystems", func(t *testing.T) {
+		rename = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		t.Cleanup(func() { rename = os.Rename })
+		move(t, "copied.txt")
+		assertNoTempFiles(t, adapter.root)
+	})
+
+	if err := adapter.Move(ctx, StagingKey("missing.txt"), "missing.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Move of a missing object = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestLocalFSAdapterRejectsInvalidNames(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", ".", "..", "../escape.txt", "a/b.txt", `a\b.txt`, tempPrefix + "x"} {
+		if err := adapter.Put(ctx, key, strings.NewReader("x"), ObjectMeta{}); !errors.Is(err, ErrInvalidObjectName) {
+			t.Errorf("Put(%q) = %v, want ErrInvalidObjectName", key, err)
+		}
+		if _, _, err := adapter.Get(ctx, key); !errors.Is(err, ErrInvalidObjectName) {
+			t.Errorf("Get(%q) = %v, want ErrInvalidObjectName", key, err)
+		}
+		if err := adapter.Move(ctx, key, "dst.txt"); !errors.Is(err, ErrInvalidObjectName) {
+			t.Errorf("Move(%q) = %v, want ErrInvalidObjectName", key, err)
+		}
+		if _, err := adapter.SignedURL(ctx, key, time.Minute); !errors.Is(err, ErrInvalidObjectName) {
+			t.Errorf("SignedURL(%q) = %v, want ErrInvalidObjectName", key, err)
+		}
+		if url := adapter.URL(key); url != "" {
+			t.Errorf("URL(%q) = %q, want none", key, url)
+		}
+	}
+}
+
+func TestLocalFSAdapterURL(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+
+	path, _ := adapter.path("a.png")
+	rel, _ := filepath.Rel(adapter.root, path)
+	if got, want := adapter.URL("a.png"), "http://localhost:3000/public/storage/"+filepath.ToSlash(rel); got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestLocalFSAdapterSignedURLs(t *testing.T) {
+	adapter := newTestLocalFSAdapter(t)
+	ctx := context.Background()
+
+	downloadURL, err := adapter.SignedURL(ctx, "a b.png", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	key, expires, signature := parseSignedURL(t, downloadURL)
+	if key != "a b.png" {
+		t.Errorf("signed URL points at %q, want %q", key, "a b.png")
+	}
+	if err := adapter.VerifySignature(key, expires, signature); err != nil {
+		t.Errorf("VerifySignature of a fresh link = %v", err)
+	}
+	if err := adapter.VerifyUploadSignature(key, expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("download link accepted for an upload: %v", err)
+	}
+
+	uploadURL, err := adapter.SignedUploadURL(ctx, "a b.png", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedUploadURL: %v", err)
+	}
+	key, expires, signature = parseSignedURL(t, uploadURL)
+	if err := adapter.VerifyUploadSignature(key, expires, signature); err != nil {
+		t.Errorf("VerifyUploadSignature of a fresh link = %v", err)
+	}
+	if err := adapter.VerifySignature(key, expires, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("upload link accepted for a download: %v", err)
+	}
+
+	tampered := []struct {
+		name                    string
+		key, expires, signature string
+	}{
+		{"other key", "b.png", expires, signature},
+		{"later expiry", key, expires + "0", signature},
+		{"altered signature", key, expires, strings.Repeat("0", len(signature))},
+		{"malformed expiry", key, "soon", signature},
+	}
+	for _, tc := range tampered {
+		if err := adapter.VerifyUploadSignature(tc.key, tc.expires, tc.signature); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: VerifyUploadSignature = %v, want ErrInvalidSignature", tc.name, err)
+		}
+	}
+
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	if err := adapter.VerifySignature("a.png", past, adapter.sign("a.png", past)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature of an expired link = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// parseSignedURL splits a link made by the adapter into its key, expiry and signature.
+func parseSignedURL(t *testing.T, link string) (key, expires, signature string) {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("signed URL %q: %v", link, err)
+	}
+	key, ok := strings.CutPrefix(parsed.Path, "/api/v1/files/")
+	if parsed.Host != "localhost:3000" || !ok {
+		t.Fatalf("signed URL %q does not point at the signed-link route", link)
+	}
+	query := parsed.Query()
+	return key, query.Get("expires"), query.Get("signature")
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the size of the parts a streamed upload is split into. Each
+// upload buffers one part in memory, so it is kept close to the S3 minimum.
+const s3PartSize = 16 << 20
+
+// s3ConnectTimeout bounds the bucket check made when the adapter is created.
+const s3ConnectTimeout = 10 * time.Second
+
+// S3Options configures an S3Adapter.
+type S3Options struct {
+	Endpoint  string // Host and optional port, without a scheme
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool   // Address the bucket in the path instead of the host name, as MinIO usually needs
+	PublicURL string // Base URL objects are served from. Defaults to the bucket's own URL.
+}
+
+// S3Adapter stores objects in a bucket of any S3-compatible service, such as AWS S3, MinIO or Cloudflare R2.
+type S3Adapter struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3Adapter creates an adapter for the configured bucket, which must already exist.
+func NewS3Adapter(opts S3Options) (*S3Adapter, error) {
+	lookup := minio.BucketLookupAuto
+	if opts.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       opts.UseSSL,
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3ConnectTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach bucket %q: %w", opts.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %q does not exist", opts.Bucket)
+	}
+
+	publicURL := strings.TrimRight(opts.PublicURL, "/")
+	if publicURL == "" {
+		scheme := "http://"
+		if opts.UseSSL {
+			scheme = "https://"
+		}
+		if opts.PathStyle {
+			publicURL = scheme + opts.Endpoint + "/" + opts.Bucket
+		} else {
+			publicURL = scheme + opts.Bucket + "." + opts.Endpoint
+		}
+	}
+
+	return &S3Adapter{client: client, bucket: opts.Bucket, publicURL: publicURL}, nil
+}
+
+// Put streams the content into the bucket. S3 makes the object visible only
+// once the upload has completed, so readers never see a partial object.
+func (a *S3Adapter) Put(ctx context.Context, key string, content io.Reader, meta ObjectMeta) error {
+	if !validObjectName(key) {
+		return ErrInvalidObjectName
+	}
+
+	if meta.ContentType == "" {
+		meta.ContentType = contentType(key)
+	}
+	size := meta.Size
+	if size <= 0 {
+		size = -1 // Unknown sizes are streamed in parts
+	}
+	_, err := a.client.PutObject(ctx, a.bucket, key, content, size, minio.PutObjectOptions{
+		ContentType: meta.ContentType,
+		PartSize:    s3PartSize,
+	})
+	return err
+}
+
+// Get opens the object for reading.
+func (a *S3Adapter) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if !validObjectName(key) {
+		return nil, nil, ErrInvalidObjectName
+	}
+
+	object, err := a.client.GetObject(ctx, a.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+	// The request is only sent once the object is first used
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, s3Error(err)
+	}
+	return object, s3ObjectInfo(stat), nil
+}
+
+// Delete removes the object. Deleting an object that does not exist is a no-op.
+func (a *S3Adapter) Delete(ctx context.Context, key string) error {
+	if !validObjectName(key) {
+		return ErrInvalidObjectName
+	}
+	return a.client.RemoveObject(ctx, a.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// Move copies the object within the bucket and deletes the original, as S3 cannot rename objects.
+func (a *S3Adapter) Move(ctx context.Context, src, dst string) error {
+	if !validObjectName(src) || !validObjectName(dst) {
+		return ErrInvalidObjectName
+	}
+
+	_, err := a.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: a.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: a.bucket, Object: src})
+	if err != nil {
+		return s3Error(err)
+	}
+	return a.Delete(ctx, src)
+}
+
+// Stat describes the object without downloading it.
+func (a *S3Adapter) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	if !validObjectName(key) {
+		return nil, ErrInvalidObjectName
+	}
+
+	stat, err := a.client.StatObject(ctx, a.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return s3ObjectInfo(stat), nil
+}
+
+// List returns every object in the bucket whose key starts with prefix.
+func (a *S3Adapter) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for object := range a.client.ListObjects(ctx, a.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		objects = append(objects, *s3ObjectInfo(object))
+	}
+	return objects, nil
+}
+
+// URL returns the address of the object below the public URL.
+func (a *S3Adapter) URL(key string) string {
+	return a.publicURL + "/" + url.PathEscape(key)
+}
+
+// SignedURL returns a presigned link to the object, which works even when the bucket is private.
+func (a *S3Adapter) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if !validObjectName(key) {
+		return "", ErrInvalidObjectName
+	}
+
+	signed, err := a.client.PresignedGetObject(ctx, a.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// SignedUploadURL returns a presigned link that stores whatever is sent to it with PUT.
+func (a *S3Adapter) SignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if !validObjectName(key) {
+		return "", ErrInvalidObjectName
+	}
+
+	signed, err := a.client.PresignedPutObject(ctx, a.bucket, key, expiry)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// s3Error maps a missing object to ErrObjectNotFound.
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrObjectNotFound
+	}
+	return err
+}
+
+// s3ObjectInfo converts the client's description of an object.
+func s3ObjectInfo(object minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          object.Key,
+		Size:         object.Size,
+		ContentType:  object.ContentType,
+		LastModified: object.LastModified,
+	}
+}
@@ -0,0 +1,419 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testBucket = "test-bucket"
+
+// fakeObject is an object held by fakeS3.
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// fakeS3 answers the path-style requests the adapter makes for a single bucket, keeping objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	uploads map[string]*fakeUpload
+}
+
+// fakeUpload is a multipart upload under way, used for objects of unknown size.
+type fakeUpload struct {
+	key         string
+	contentType string
+	parts       map[int][]byte
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{objects: make(map[string]fakeObject), uploads: make(map[string]*fakeUpload)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, query.Get("prefix"))
+	case query.Has("uploads") || query.Has("uploadId"):
+		f.multipart(w, r, key)
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		f.copy(w, r.Header.Get("X-Amz-Copy-Source"), key)
+	case r.Method == http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modified: time.Now().UTC()}
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("Last-Modified", object.modified.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// multipart answers the requests that start, fill, complete and abort a multipart upload.
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	if r.Method == http.MethodPost && query.Has("uploads") {
+		id := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[id] = &fakeUpload{key: key, contentType: r.Header.Get("Content-Type"), parts: make(map[int][]byte)}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`,
+			testBucket, key, id)
+		return
+	}
+
+	upload, ok := f.uploads[query.Get("uploadId")]
+	if !ok || upload.key != key {
+		writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		number, err := strconv.Atoi(query.Get("partNumber"))
+		data, bodyErr := readS3Body(r)
+		if err != nil || bodyErr != nil {
+			writeS3Error(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+		upload.parts[number] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodPost:
+		var data []byte
+		for number := 1; number <= len(upload.parts); number++ {
+			data = append(data, upload.parts[number]...)
+		}
+		f.objects[key] = fakeObject{data: data, contentType: upload.contentType, modified: time.Now().UTC()}
+		delete(f.uploads, query.Get("uploadId"))
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"fake"</ETag></CompleteMultipartUploadResult>`,
+			testBucket, key)
+	case http.MethodDelete:
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// copy answers a CopyObject request.
+func (f *fakeS3) copy(w http.ResponseWriter, source, key string) {
+	source, _ = url.PathUnescape(source)
+	object, ok := f.objects[strings.TrimPrefix(strings.TrimPrefix(source, "/"), testBucket+"/")]
+	if !ok {
+		writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+	object.modified = time.Now().UTC()
+	f.objects[key] = object
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprintf(w, `<CopyObjectResult><ETag>"fake"</ETag><LastModified>%s</LastModified></CopyObjectResult>`,
+		object.modified.Format(time.RFC3339))
+}
+
+// list answers a ListObjectsV2 request.
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type contents struct {
+		Key          string
+		Size         int64
+		LastModified string
+		ETag         string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []contents
+	}{Name: testBucket, Prefix: prefix, MaxKeys: 1000}
+
+	for key, object := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, contents{
+				Key:          key,
+				Size:         int64(len(object.data)),
+				LastModified: object.modified.Format(time.RFC3339),
+				ETag:         `"fake"`,
+			})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// readS3Body reads an uploaded object, decoding the aws-chunked encoding of streaming signatures.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2) // The chunk is followed by CRLF
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
+
+func newTestS3Adapter(t *testing.T, server *httptest.Server, publicURL string) *S3Adapter {
+	t.Helper()
+	adapter, err := NewS3Adapter(S3Options{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    testBucket,
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Adapter: %v", err)
+	}
+	return adapter
+}
+
+func TestS3AdapterPutGetDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "")
+	ctx := context.Background()
+	content := []byte("hello from the fake bucket")
+
+	for _, size := range []int64{int64(len(content)), -1} {
+		delete(fake.objects, "hello.txt")
+		err := adapter.Put(ctx, "hello.txt", bytes.NewReader(content), ObjectMeta{Size: size})
+		if err != nil {
+			t.Fatalf("Put with size %d: %v", size, err)
+		}
+		if got := fake.objects["hello.txt"]; !bytes.Equal(got.data, content) || got.contentType != "text/plain; charset=utf-8" {
+			t.Fatalf("stored %q as %q, want %q as text/plain", got.data, got.contentType, content)
+		}
+	}
+
+	reader, info, err := adapter.Get(ctx, "hello.txt")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("Get read %q, %v; want %q", got, err, content)
+	}
+	if info.Key != "hello.txt" || info.Size != int64(len(content)) {
+		t.Errorf("Get info = %+v", info)
+	}
+
+	objects, err := adapter.List(ctx, "hello")
+	if err != nil || len(objects) != 1 || objects[0].Key != "hello.txt" {
+		t.Fatalf("List = %+v, %v", objects, err)
+	}
+
+	if err := adapter.Delete(ctx, "hello.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := adapter.Stat(ctx, "hello.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Stat after Delete = %v, want ErrObjectNotFound", err)
+	}
+	if _, _, err := adapter.Get(ctx, "hello.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrObjectNotFound", err)
+	}
+	if err := adapter.Delete(ctx, "hello.txt"); err != nil {
+		t.Errorf("Delete of a missing object = %v, want nil", err)
+	}
+}
+
+func TestS3AdapterMove(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "")
+	ctx := context.Background()
+
+	staged := StagingKey("moved.txt")
+	if err := adapter.Put(ctx, staged, strings.NewReader("checked"), ObjectMeta{Size: 7}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := adapter.Move(ctx, staged, "moved.txt"); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if _, ok := fake.objects[staged]; ok {
+		t.Error("Move left the source object behind")
+	}
+	if got := string(fake.objects["moved.txt"].data); got != "checked" {
+		t.Errorf("moved object holds %q, want %q", got, "checked")
+	}
+	if err := adapter.Move(ctx, staged, "moved.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Move of a missing object = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestS3AdapterRejectsInvalidNames(t *testing.T) {
+	_, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "")
+	ctx := context.Background()
+
+	if err := adapter.Put(ctx, "../escape", strings.NewReader("x"), ObjectMeta{}); !errors.Is(err, ErrInvalidObjectName) {
+		t.Errorf("Put = %v, want ErrInvalidObjectName", err)
+	}
+	if _, err := adapter.SignedUploadURL(ctx, "a/b", time.Minute); !errors.Is(err, ErrInvalidObjectName) {
+		t.Errorf("SignedUploadURL = %v, want ErrInvalidObjectName", err)
+	}
+}
+
+func TestS3AdapterMissingBucket(t *testing.T) {
+	_, server := newFakeS3(t)
+	_, err := NewS3Adapter(S3Options{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "missing",
+		PathStyle: true,
+	})
+	if err == nil {
+		t.Fatal("NewS3Adapter succeeded for a bucket that does not exist")
+	}
+}
+
+func TestS3AdapterURL(t *testing.T) {
+	_, server := newFakeS3(t)
+
+	adapter := newTestS3Adapter(t, server, "")
+	if got, want := adapter.URL("a b.png"), server.URL+"/"+testBucket+"/a%20b.png"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+
+	adapter = newTestS3Adapter(t, server, "https://cdn.example.com/")
+	if got, want := adapter.URL("a.png"), "https://cdn.example.com/a.png"; got != want {
+		t.Errorf("URL with public URL = %q, want %q", got, want)
+	}
+}
+
+func TestS3AdapterPresignedURLs(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "")
+	ctx := context.Background()
+
+	uploadURL, err := adapter.SignedUploadURL(ctx, "direct.bin", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedUploadURL: %v", err)
+	}
+	assertPresigned(t, uploadURL, server.URL+"/"+testBucket+"/direct.bin", "600")
+
+	req, _ := http.NewRequest(http.MethodPut, uploadURL, strings.NewReader("sent straight to storage"))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT to the signed upload URL: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(fake.objects["direct.bin"].data) != "sent straight to storage" {
+		t.Fatalf("PUT to the signed upload URL stored %q with status %d", fake.objects["direct.bin"].data, resp.StatusCode)
+	}
+
+	downloadURL, err := adapter.SignedURL(ctx, "direct.bin", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	assertPresigned(t, downloadURL, server.URL+"/"+testBucket+"/direct.bin", "60")
+
+	resp, err = http.Get(downloadURL)
+	if err != nil {
+		t.Fatalf("GET of the signed URL: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "sent straight to storage" {
+		t.Errorf("GET of the signed URL = %q", body)
+	}
+}
+
+// assertPresigned checks that a link points at the object and carries a SigV4 signature with the expiry.
+func assertPresigned(t *testing.T, link, object, expires string) {
+	t.Helper()
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("presigned URL %q: %v", link, err)
+	}
+	if base := parsed.Scheme + "://" + parsed.Host + parsed.Path; base != object {
+		t.Errorf("presigned URL points at %q, want %q", base, object)
+	}
+	query := parsed.Query()
+	if query.Get("X-Amz-Algorithm") != "AWS4-HMAC-SHA256" || query.Get("X-Amz-Signature") == "" {
+		t.Errorf("presigned URL %q is not signed with SigV4", link)
+	}
+	if query.Get("X-Amz-Expires") != expires {
+		t.Errorf("presigned URL expires in %q seconds, want %s", query.Get("X-Amz-Expires"), expires)
+	}
+}
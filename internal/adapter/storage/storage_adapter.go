@@ -2,28 +2,77 @@ package storage
 
 import (
 	"context"
-	"log/slog"
-	"mime/multipart"
+	"errors"
+	"io"
+	"strings"
+	"time"
 )
 
-// StorageAdapter defines the interface for any cloud storage service.
-type StorageAdapter interface {
-	Upload(ctx context.Context, file *multipart.FileHeader) (string, error)
+// ErrObjectNotFound is returned when no object is stored under the requested key.
+var ErrObjectNotFound = errors.New("object not found")
+
+// stagingPrefix marks the keys of objects that were uploaded but not yet checked.
+const stagingPrefix = "staging-"
+
+// heldPrefix marks, within the staging keys, objects taken over from their upload link.
+const heldPrefix = "held-"
+
+// StagingKey returns the key an object is uploaded under before it is checked and moved to key.
+// Adapters keep staged objects out of public reach: the local one stores them outside its
+// served root, and S3 buckets must leave keys starting with "staging-" private.
+func StagingKey(key string) string {
+	return stagingPrefix + key
 }
 
-// GCSAdapter is the placeholder implementation for Google Cloud Storage.
-type GCSAdapter struct {
-	bucketName string
+// HeldKey returns the staging key an uploaded object is moved to before it is checked. No upload
+// link is ever signed for it, so its content cannot be replaced while it is checked and scanned,
+// even though the link it was uploaded with stays valid until it expires.
+func HeldKey(key string) string {
+	return stagingPrefix + heldPrefix + key
 }
 
-// NewGCSAdapter creates a new instance of our GCS placeholder.
-func NewGCSAdapter(bucketName string) *GCSAdapter {
-	return &GCSAdapter{bucketName: bucketName}
+// StagedKey returns the key a staged or held object is meant for, and whether key is a staging
+// key at all.
+func StagedKey(key string) (string, bool) {
+	key, staged := strings.CutPrefix(key, stagingPrefix)
+	if !staged {
+		return key, false
+	}
+	return strings.TrimPrefix(key, heldPrefix), true
 }
 
-// Upload simulates uploading a file to GCS.
-func (a *GCSAdapter) Upload(ctx context.Context, file *multipart.FileHeader) (string, error) {
-	slog.Info("Simulating upload of file to GCS bucket", "file", file.Filename, "bucket", a.bucketName)
-	dummyURL := "https://storage.googleapis.com/" + a.bucketName + "/" + file.Filename
-	return dummyURL, nil
+// ObjectMeta describes content being stored.
+type ObjectMeta struct {
+	ContentType string
+	Size        int64 // 0 or -1 when unknown
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// StorageAdapter defines the interface for any cloud storage service.
+type StorageAdapter interface {
+	// Put stores content under key, replacing any earlier object of that key.
+	Put(ctx context.Context, key string, content io.Reader, meta ObjectMeta) error
+	// Get opens the object for reading. The caller must close it.
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes the object. Deleting an object that does not exist is a no-op.
+	Delete(ctx context.Context, key string) error
+	// Move renames the object from src to dst, replacing any earlier object of dst.
+	Move(ctx context.Context, src, dst string) error
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// List returns every object whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// URL returns the public address of the object.
+	URL(key string) string
+	// SignedURL returns a link that gives read access to the object until it expires.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// SignedUploadURL returns a link the object's content can be sent to with a PUT request
+	// until it expires, so clients can upload without the bytes passing through the API.
+	SignedUploadURL(ctx context.Context, key string, expiry time.Duration) (string, error)
 }
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultStatsDays is how many days the stats cover when no range is given.
+const defaultStatsDays = 30
+
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler.
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetPostStats is the handler for the traffic statistics of a post.
+// @Summary      Get post statistics
+// @Description  Returns the daily views, unique visitors and top referrers of a post over a date range. Repeated views by the same visitor within a short window count once. Only the author and co-owners can see them.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      string  true   "Post ID"
+// @Param        from  query     string  false  "First day (YYYY-MM-DD). Defaults to 29 days before to."
+// @Param        to    query     string  false  "Last day (YYYY-MM-DD). Defaults to today."
+// @Success      200   {object}  response.ApiResponse{data=service.PostStats} "Successfully retrieved statistics"
+// @Failure      400   {object}  response.ApiResponse "Bad Request"
+// @Failure      401   {object}  response.ApiResponse "Unauthorized"
+// @Failure      403   {object}  response.ApiResponse "Forbidden"
+// @Failure      404   {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/stats [get]
+func (h *AnalyticsHandler) GetPostStats(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	to := time.Now()
+	if param := c.Query("to"); param != "" {
+		if to, err = time.ParseInLocation(time.DateOnly, param, time.Local); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid to date, expected YYYY-MM-DD"))
+		}
+	}
+	from := to.AddDate(0, 0, -(defaultStatsDays - 1))
+	if param := c.Query("from"); param != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, param, time.Local); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid from date, expected YYYY-MM-DD"))
+		}
+	}
+
+	stats, err := h.analyticsService.GetPostStats(postID, userID, from, to)
+	if err != nil {
+		return analyticsError(c, err, "could not retrieve statistics")
+	}
+
+	return response.Success(c, fiber.StatusOK, stats)
+}
+
+// analyticsError maps an analytics service error to an HTTP response.
+func analyticsError(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, policy.ErrForbidden) {
+		return response.Error(c, fiber.StatusForbidden, err)
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
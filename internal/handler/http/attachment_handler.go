@@ -0,0 +1,214 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler.
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+// UpdateAttachmentPayload defines the expected JSON for updating an attachment.
+type UpdateAttachmentPayload struct {
+	AltText string `json:"alt_text" validate:"max=500"`
+}
+
+// ReorderAttachmentsPayload defines the expected JSON for reordering attachments.
+type ReorderAttachmentsPayload struct {
+	AttachmentIDs []uuid.UUID `json:"attachment_ids" validate:"required"`
+}
+
+// UploadAttachments is the handler for adding attachments to a post.
+// @Summary      Upload post attachments
+// @Description  Uploads one or more files to a post. Each new attachment is returned with status "local" once saved on the server, then moves to "cloud" as it is stored in the background, to "failed" if every retry fails, or to "quarantined" if the malware scanner flags the file.
+// @Tags         Attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id        path      string  true   "Post ID"
+// @Param        files     formData  file    true   "Files to attach"
+// @Param        alt_text  formData  string  false  "Alt text, one per file in the same order"
+// @Success      201       {object}  response.ApiResponse{data=[]model.PostAttachment} "Successfully uploaded attachments"
+// @Failure      400       {object}  response.ApiResponse "Bad Request"
+// @Failure      401       {object}  response.ApiResponse "Unauthorized"
+// @Failure      403       {object}  response.ApiResponse "Forbidden"
+// @Failure      404       {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachments(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid multipart form"))
+	}
+
+	attachments, err := h.attachmentService.UploadAttachments(postID, userID, form.File["files"], form.Value["alt_text"])
+	if err != nil {
+		return attachmentError(c, err, "could not upload attachments")
+	}
+
+	return response.Success(c, fiber.StatusCreated, attachments)
+}
+
+// UpdateAttachment is the handler for changing the alt text of an attachment.
+// @Summary      Update a post attachment
+// @Description  Changes the alt text of one of the post's attachments.
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id            path      string                   true  "Post ID"
+// @Param        attachmentId  path      string                   true  "Attachment ID"
+// @Param        payload       body      UpdateAttachmentPayload  true  "Attachment Update Payload"
+// @Success      200           {object}  response.ApiResponse{data=model.PostAttachment} "Successfully updated attachment"
+// @Failure      400           {object}  response.ApiResponse "Bad Request"
+// @Failure      401           {object}  response.ApiResponse "Unauthorized"
+// @Failure      403           {object}  response.ApiResponse "Forbidden"
+// @Failure      404           {object}  response.ApiResponse "Post or attachment not found"
+// @Router       /posts/{id}/attachments/{attachmentId} [patch]
+func (h *AttachmentHandler) UpdateAttachment(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid attachment ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(UpdateAttachmentPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	attachment, err := h.attachmentService.UpdateAltText(postID, attachmentID, userID, payload.AltText)
+	if err != nil {
+		return attachmentError(c, err, "could not update attachment")
+	}
+
+	return response.Success(c, fiber.StatusOK, attachment)
+}
+
+// ReorderAttachments is the handler for changing the display order of a post's attachments.
+// @Summary      Reorder post attachments
+// @Description  Sets the display order of the post's attachments. Every attachment must be listed exactly once.
+// @Tags         Attachments
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                     true  "Post ID"
+// @Param        payload  body      ReorderAttachmentsPayload  true  "Attachment Order Payload"
+// @Success      200      {object}  response.ApiResponse{data=[]model.PostAttachment} "Successfully reordered attachments"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/attachments/order [put]
+func (h *AttachmentHandler) ReorderAttachments(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(ReorderAttachmentsPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	attachments, err := h.attachmentService.ReorderAttachments(postID, userID, payload.AttachmentIDs)
+	if err != nil {
+		return attachmentError(c, err, "could not reorder attachments")
+	}
+
+	return response.Success(c, fiber.StatusOK, attachments)
+}
+
+// DeleteAttachment is the handler for removing an attachment from a post.
+// @Summary      Delete a post attachment
+// @Description  Removes one of the post's attachments along with its stored file.
+// @Tags         Attachments
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id            path      string  true  "Post ID"
+// @Param        attachmentId  path      string  true  "Attachment ID"
+// @Success      200           {object}  response.ApiResponse "Successfully deleted attachment"
+// @Failure      400           {object}  response.ApiResponse "Bad Request"
+// @Failure      401           {object}  response.ApiResponse "Unauthorized"
+// @Failure      403           {object}  response.ApiResponse "Forbidden"
+// @Failure      404           {object}  response.ApiResponse "Post or attachment not found"
+// @Router       /posts/{id}/attachments/{attachmentId} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid attachment ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.attachmentService.DeleteAttachment(postID, attachmentID, userID); err != nil {
+		return attachmentError(c, err, "could not delete attachment")
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// attachmentError maps an attachment service error to an HTTP response.
+func attachmentError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, policy.ErrForbidden):
+		return response.Error(c, fiber.StatusForbidden, err)
+	case strings.Contains(msg, "attachment not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+	case strings.Contains(msg, "at least one file"), strings.Contains(msg, "files can be uploaded"),
+		strings.Contains(msg, "alt text"), strings.Contains(msg, "invalid order"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
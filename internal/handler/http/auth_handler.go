@@ -2,6 +2,7 @@ package http
 
 import (
 	"errors"
+	"strings"
 	"venturo-core/internal/service"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
@@ -22,6 +23,7 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 // RegisterPayload defines the expected JSON for registration.
 type RegisterPayload struct {
 	Name     string `json:"name" validate:"required,min=2"`
+	Username string `json:"username" validate:"omitempty,username"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 }
@@ -33,13 +35,14 @@ type LoginPayload struct {
 
 // Register is the handler for the user registration endpoint.
 // @Summary      Register a new user
-// @Description  Creates a new user account with the provided details.
+// @Description  Creates a new user account with the provided details. Without a username, one is generated from the name.
 // @Tags         Authentication
 // @Accept       json
 // @Produce      json
 // @Param        payload  body      RegisterPayload     true  "User Registration Payload"
 // @Success      201      {object}  response.ApiResponse "User registered successfully"
 // @Failure      400      {object}  response.ApiResponse "Bad Request - Invalid input"
+// @Failure      409      {object}  response.ApiResponse "Conflict - Email or username taken"
 // @Failure      500      {object}  response.ApiResponse "Internal Server Error"
 // @Router       /register [post]
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
@@ -56,8 +59,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// Call the service to register the user
-	err := h.authService.Register(c.Context(), payload.Name, payload.Email, payload.Password)
+	err := h.authService.Register(c.Context(), payload.Name, payload.Username, payload.Email, payload.Password)
 	if err != nil {
+		if strings.Contains(err.Error(), "already") {
+			return response.Error(c, fiber.StatusConflict, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -75,6 +81,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Success      200      {object}  response.ApiResponse "Successfully logged in"
 // @Failure      400      {object}  response.ApiResponse "Bad Request - Cannot parse JSON"
 // @Failure      401      {object}  response.ApiResponse "Unauthorized - Invalid credentials"
+// @Failure      403      {object}  response.ApiResponse "Forbidden - Account suspended"
 // @Router       /login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	payload := new(LoginPayload)
@@ -85,6 +92,9 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 
 	token, err := h.authService.Login(c.Context(), payload.Email, payload.Password)
 	if err != nil {
+		if strings.Contains(err.Error(), "suspended") {
+			return response.Error(c, fiber.StatusForbidden, err)
+		}
 		return response.Error(c, fiber.StatusUnauthorized, err)
 	}
 
@@ -0,0 +1,302 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BookmarkHandler struct {
+	bookmarkService *service.BookmarkService
+}
+
+// NewBookmarkHandler creates a new BookmarkHandler.
+func NewBookmarkHandler(bookmarkService *service.BookmarkService) *BookmarkHandler {
+	return &BookmarkHandler{bookmarkService: bookmarkService}
+}
+
+// AddBookmarkPayload defines the expected JSON for bookmarking a post.
+type AddBookmarkPayload struct {
+	PostID       uuid.UUID  `json:"post_id" validate:"required"`
+	CollectionID *uuid.UUID `json:"collection_id"`
+}
+
+// RemoveBookmarksPayload defines the expected JSON for removing bookmarks in bulk.
+type RemoveBookmarksPayload struct {
+	PostIDs []uuid.UUID `json:"post_ids" validate:"required"`
+}
+
+// CollectionPayload defines the expected JSON for creating or renaming a bookmark collection.
+type CollectionPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// RemovedBookmarks reports the result of a bulk removal.
+type RemovedBookmarks struct {
+	Removed int64 `json:"removed"`
+}
+
+// AddBookmark is the handler for bookmarking a post.
+// @Summary      Bookmark a post
+// @Description  Saves a post for later, optionally in one of the caller's collections. Bookmarking a post again moves it to the given collection.
+// @Tags         Bookmarks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      AddBookmarkPayload  true  "Bookmark Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.Bookmark} "Successfully bookmarked post"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Post or collection not found"
+// @Router       /bookmarks [post]
+func (h *BookmarkHandler) AddBookmark(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(AddBookmarkPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	bookmark, err := h.bookmarkService.AddBookmark(userID, payload.PostID, payload.CollectionID)
+	if err != nil {
+		return bookmarkError(c, err, "could not bookmark post")
+	}
+
+	return response.Success(c, fiber.StatusCreated, bookmark)
+}
+
+// GetBookmarks is the handler for listing the caller's bookmarks.
+// @Summary      List bookmarks
+// @Description  Retrieves the caller's bookmarked posts, most recently bookmarked first. Pass the returned next_cursor to get the following page.
+// @Tags         Bookmarks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        cursor      query     string  false  "Cursor returned by the previous page"
+// @Param        limit       query     int     false  "Number of items per page" default(20)
+// @Param        collection  query     string  false  "Only list bookmarks of this collection"
+// @Success      200         {object}  response.ApiResponse{data=[]model.Bookmark} "Successfully retrieved bookmarks"
+// @Failure      400         {object}  response.ApiResponse "Bad Request"
+// @Failure      401         {object}  response.ApiResponse "Unauthorized"
+// @Failure      500         {object}  response.ApiResponse "Internal Server Error"
+// @Router       /bookmarks [get]
+func (h *BookmarkHandler) GetBookmarks(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 { // Set a max limit
+		limit = 100
+	}
+
+	var collectionID *uuid.UUID
+	if collection := c.Query("collection"); collection != "" {
+		id, err := uuid.Parse(collection)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid collection ID format"))
+		}
+		collectionID = &id
+	}
+
+	bookmarks, next, err := h.bookmarkService.GetBookmarks(userID, collectionID, c.Query("cursor"), limit)
+	if err != nil {
+		return bookmarkError(c, err, "could not retrieve bookmarks")
+	}
+
+	return response.CursorPagination(c, bookmarks, next)
+}
+
+// RemoveBookmarks is the handler for removing several bookmarks at once.
+// @Summary      Remove bookmarks
+// @Description  Removes the caller's bookmarks of the given posts. Posts that were not bookmarked are ignored.
+// @Tags         Bookmarks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      RemoveBookmarksPayload  true  "Bookmarks to remove"
+// @Success      200      {object}  response.ApiResponse{data=RemovedBookmarks} "Successfully removed bookmarks"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Router       /bookmarks [delete]
+func (h *BookmarkHandler) RemoveBookmarks(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(RemoveBookmarksPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	removed, err := h.bookmarkService.RemoveBookmarks(userID, payload.PostIDs)
+	if err != nil {
+		return bookmarkError(c, err, "could not remove bookmarks")
+	}
+
+	return response.Success(c, fiber.StatusOK, RemovedBookmarks{Removed: removed})
+}
+
+// GetCollections is the handler for listing the caller's bookmark collections.
+// @Summary      List bookmark collections
+// @Description  Retrieves the caller's bookmark collections in alphabetical order.
+// @Tags         Bookmarks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=[]model.BookmarkCollection} "Successfully retrieved collections"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /bookmarks/collections [get]
+func (h *BookmarkHandler) GetCollections(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	collections, err := h.bookmarkService.GetCollections(userID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve collections"))
+	}
+
+	return response.Success(c, fiber.StatusOK, collections)
+}
+
+// CreateCollection is the handler for creating a bookmark collection.
+// @Summary      Create a bookmark collection
+// @Description  Creates a named collection to file bookmarks under. Names are unique per user.
+// @Tags         Bookmarks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CollectionPayload  true  "Collection Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.BookmarkCollection} "Successfully created collection"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      409      {object}  response.ApiResponse "Name already in use"
+// @Router       /bookmarks/collections [post]
+func (h *BookmarkHandler) CreateCollection(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(CollectionPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	collection, err := h.bookmarkService.CreateCollection(userID, payload.Name)
+	if err != nil {
+		return bookmarkError(c, err, "could not create collection")
+	}
+
+	return response.Success(c, fiber.StatusCreated, collection)
+}
+
+// RenameCollection is the handler for renaming a bookmark collection.
+// @Summary      Rename a bookmark collection
+// @Description  Changes the name of one of the caller's collections.
+// @Tags         Bookmarks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string             true  "Collection ID"
+// @Param        payload  body      CollectionPayload  true  "Collection Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.BookmarkCollection} "Successfully renamed collection"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Collection not found"
+// @Failure      409      {object}  response.ApiResponse "Name already in use"
+// @Router       /bookmarks/collections/{id} [put]
+func (h *BookmarkHandler) RenameCollection(c *fiber.Ctx) error {
+	collectionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(CollectionPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	collection, err := h.bookmarkService.RenameCollection(userID, collectionID, payload.Name)
+	if err != nil {
+		return bookmarkError(c, err, "could not rename collection")
+	}
+
+	return response.Success(c, fiber.StatusOK, collection)
+}
+
+// DeleteCollection is the handler for deleting a bookmark collection.
+// @Summary      Delete a bookmark collection
+// @Description  Deletes one of the caller's collections. Its bookmarks are kept, without a collection.
+// @Tags         Bookmarks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Collection ID"
+// @Success      200  {object}  response.ApiResponse "Successfully deleted collection"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Collection not found"
+// @Router       /bookmarks/collections/{id} [delete]
+func (h *BookmarkHandler) DeleteCollection(c *fiber.Ctx) error {
+	collectionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.bookmarkService.DeleteCollection(userID, collectionID); err != nil {
+		return bookmarkError(c, err, "could not delete collection")
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// bookmarkError maps a bookmark service error to an HTTP response.
+func bookmarkError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "already exists"):
+		return response.Error(c, fiber.StatusConflict, err)
+	case strings.Contains(msg, "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
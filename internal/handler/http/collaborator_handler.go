@@ -0,0 +1,209 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CollaboratorHandler struct {
+	collaboratorService *service.CollaboratorService
+}
+
+// NewCollaboratorHandler creates a new CollaboratorHandler.
+func NewCollaboratorHandler(collaboratorService *service.CollaboratorService) *CollaboratorHandler {
+	return &CollaboratorHandler{collaboratorService: collaboratorService}
+}
+
+// InviteCollaboratorPayload defines the expected JSON for inviting a collaborator to a post.
+type InviteCollaboratorPayload struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+	Role   string    `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+// GetCollaborators is the handler for listing the collaborators of a post.
+// @Summary      List post collaborators
+// @Description  Lists the collaborators of a post, including pending invitations. Only the author and collaborators can see them.
+// @Tags         Collaborators
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Post ID"
+// @Success      200  {object}  response.ApiResponse{data=[]model.PostCollaborator} "Successfully retrieved collaborators"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      403  {object}  response.ApiResponse "Forbidden"
+// @Failure      404  {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/collaborators [get]
+func (h *CollaboratorHandler) GetCollaborators(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	collaborators, err := h.collaboratorService.GetCollaborators(postID, userID)
+	if err != nil {
+		return collaboratorError(c, err, "could not retrieve collaborators")
+	}
+
+	return response.Success(c, fiber.StatusOK, collaborators)
+}
+
+// InviteCollaborator is the handler for inviting a collaborator to a post.
+// @Summary      Invite a collaborator
+// @Description  Invites a user to collaborate on a post as owner, editor or viewer. The invitation takes effect once accepted. Inviting someone again changes their role. Only the author and co-owners can invite.
+// @Tags         Collaborators
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                     true  "Post ID"
+// @Param        payload  body      InviteCollaboratorPayload  true  "Invitation Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.PostCollaborator} "Successfully invited collaborator"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Post or user not found"
+// @Router       /posts/{id}/collaborators [post]
+func (h *CollaboratorHandler) InviteCollaborator(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(InviteCollaboratorPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	collaborator, err := h.collaboratorService.InviteCollaborator(postID, userID, payload.UserID, payload.Role)
+	if err != nil {
+		return collaboratorError(c, err, "could not invite collaborator")
+	}
+
+	return response.Success(c, fiber.StatusCreated, collaborator)
+}
+
+// AcceptInvitation is the handler for accepting an invitation to collaborate on a post.
+// @Summary      Accept a collaboration invitation
+// @Description  Accepts the caller's invitation to collaborate on a post, granting the invited role.
+// @Tags         Collaborators
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Post ID"
+// @Success      200  {object}  response.ApiResponse{data=model.PostCollaborator} "Successfully accepted invitation"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Invitation not found"
+// @Router       /posts/{id}/collaborators/accept [post]
+func (h *CollaboratorHandler) AcceptInvitation(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	collaborator, err := h.collaboratorService.AcceptInvitation(postID, userID)
+	if err != nil {
+		return collaboratorError(c, err, "could not accept invitation")
+	}
+
+	return response.Success(c, fiber.StatusOK, collaborator)
+}
+
+// RemoveCollaborator is the handler for removing a collaborator from a post.
+// @Summary      Remove a collaborator
+// @Description  Ends a collaboration or withdraws an invitation. Users can remove themselves, which also declines an invitation; removing others is limited to the author and co-owners.
+// @Tags         Collaborators
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true  "Post ID"
+// @Param        userId  path      string  true  "User ID"
+// @Success      200     {object}  response.ApiResponse "Successfully removed collaborator"
+// @Failure      400     {object}  response.ApiResponse "Bad Request"
+// @Failure      401     {object}  response.ApiResponse "Unauthorized"
+// @Failure      403     {object}  response.ApiResponse "Forbidden"
+// @Failure      404     {object}  response.ApiResponse "Collaborator not found"
+// @Router       /posts/{id}/collaborators/{userId} [delete]
+func (h *CollaboratorHandler) RemoveCollaborator(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	collaboratorID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.collaboratorService.RemoveCollaborator(postID, userID, collaboratorID); err != nil {
+		return collaboratorError(c, err, "could not remove collaborator")
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// GetInvitations is the handler for listing the caller's pending invitations.
+// @Summary      List collaboration invitations
+// @Description  Lists the invitations to collaborate on posts that the caller has not answered yet, newest first.
+// @Tags         Collaborators
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=[]model.PostCollaborator} "Successfully retrieved invitations"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /collaborations/invitations [get]
+func (h *CollaboratorHandler) GetInvitations(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	invitations, err := h.collaboratorService.GetInvitations(userID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve invitations"))
+	}
+
+	return response.Success(c, fiber.StatusOK, invitations)
+}
+
+// collaboratorError maps a collaborator service error to an HTTP response.
+func collaboratorError(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, policy.ErrForbidden) {
+		return response.Error(c, fiber.StatusForbidden, err)
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "cannot"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
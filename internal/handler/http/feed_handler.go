@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FeedHandler struct {
+	feedService *service.FeedService
+}
+
+// NewFeedHandler creates a new FeedHandler.
+func NewFeedHandler(feedService *service.FeedService) *FeedHandler {
+	return &FeedHandler{feedService: feedService}
+}
+
+// GetSiteFeed is the handler for the site-wide posts feed.
+// @Summary      Site-wide posts feed
+// @Description  Serves the latest posts as RSS 2.0, Atom 1.0 or JSON Feed 1.1. Supports conditional requests through ETag and Last-Modified.
+// @Tags         Feeds
+// @Produce      xml
+// @Produce      json
+// @Param        format  path      string  true  "Feed format" Enums(rss, atom, json)
+// @Success      200     {string}  string  "The feed document"
+// @Success      304     "Not modified"
+// @Failure      400     {object}  response.ApiResponse "Invalid feed format"
+// @Router       /feeds/posts/{format} [get]
+func (h *FeedHandler) GetSiteFeed(c *fiber.Ctx) error {
+	feed, err := h.feedService.SiteFeed(c.Params("format"))
+	if err != nil {
+		return feedError(c, err)
+	}
+	return sendFeed(c, feed)
+}
+
+// GetAuthorFeed is the handler for the posts feed of a single author.
+// @Summary      Author posts feed
+// @Description  Serves an author's latest posts as RSS 2.0, Atom 1.0 or JSON Feed 1.1. Supports conditional requests through ETag and Last-Modified.
+// @Tags         Feeds
+// @Produce      xml
+// @Produce      json
+// @Param        id      path      string  true  "Author ID"
+// @Param        format  path      string  true  "Feed format" Enums(rss, atom, json)
+// @Success      200     {string}  string  "The feed document"
+// @Success      304     "Not modified"
+// @Failure      400     {object}  response.ApiResponse "Invalid feed format"
+// @Failure      404     {object}  response.ApiResponse "Author not found"
+// @Router       /feeds/authors/{id}/{format} [get]
+func (h *FeedHandler) GetAuthorFeed(c *fiber.Ctx) error {
+	authorID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	feed, err := h.feedService.AuthorFeed(authorID, c.Params("format"))
+	if err != nil {
+		return feedError(c, err)
+	}
+	return sendFeed(c, feed)
+}
+
+// GetTagFeed is the handler for the posts feed of a tag.
+// @Summary      Tag posts feed
+// @Description  Serves the latest posts with a tag as RSS 2.0, Atom 1.0 or JSON Feed 1.1. Supports conditional requests through ETag and Last-Modified.
+// @Tags         Feeds
+// @Produce      xml
+// @Produce      json
+// @Param        tag     path      string  true  "Tag"
+// @Param        format  path      string  true  "Feed format" Enums(rss, atom, json)
+// @Success      200     {string}  string  "The feed document"
+// @Success      304     "Not modified"
+// @Failure      400     {object}  response.ApiResponse "Invalid tag or feed format"
+// @Router       /feeds/tags/{tag}/{format} [get]
+func (h *FeedHandler) GetTagFeed(c *fiber.Ctx) error {
+	feed, err := h.feedService.TagFeed(c.Params("tag"), c.Params("format"))
+	if err != nil {
+		return feedError(c, err)
+	}
+	return sendFeed(c, feed)
+}
+
+// sendFeed writes the feed with its cache validators, or a 304 when the reader's copy is current.
+func sendFeed(c *fiber.Ctx, feed *service.RenderedFeed) error {
+	c.Set(fiber.HeaderETag, feed.ETag)
+	if !feed.LastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, feed.LastModified.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	if notModified(c, feed.ETag, feed.LastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, feed.ContentType)
+	return c.Status(fiber.StatusOK).SendString(feed.Body)
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since as RFC 9110 prescribes.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := c.Get(fiber.HeaderIfModifiedSince); since != "" && !lastModified.IsZero() {
+		sinceTime, err := http.ParseTime(since)
+		// HTTP dates have second precision, so compare at that precision
+		return err == nil && !lastModified.Truncate(time.Second).After(sinceTime)
+	}
+	return false
+}
+
+// feedError maps a feed service error to an HTTP response.
+func feedError(c *fiber.Ctx, err error) error {
+	if strings.Contains(err.Error(), "invalid") {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return response.Error(c, fiber.StatusNotFound, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New("could not build feed"))
+}
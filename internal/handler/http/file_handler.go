@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileHandler struct {
+	fileService *service.FileService
+}
+
+// NewFileHandler creates a new FileHandler.
+func NewFileHandler(fileService *service.FileService) *FileHandler {
+	return &FileHandler{fileService: fileService}
+}
+
+// GetSignedFile is the handler for time-limited links to stored files.
+// @Summary      Download a file through a signed link
+// @Description  Streams a stored file. The link, including its expiry and signature, is handed out by the API; it stops working once it expires or if any part of it is changed.
+// @Tags         Files
+// @Produce      octet-stream
+// @Param        key        path   string  true  "File key"
+// @Param        expires    query  int     true  "Unix time the link expires at"
+// @Param        signature  query  string  true  "Signature of the link"
+// @Success      200  {file}    file "The file"
+// @Failure      403  {object}  response.ApiResponse "Invalid or expired link"
+// @Failure      404  {object}  response.ApiResponse "File not found"
+// @Router       /files/{key} [get]
+func (h *FileHandler) GetSignedFile(c *fiber.Ctx) error {
+	file, info, err := h.fileService.OpenSignedFile(c.UserContext(), c.Params("key"), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return fileError(c, err, "could not open file")
+	}
+
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	// The stream closes the file once it has been sent
+	return c.SendStream(file, int(info.Size))
+}
+
+// PutSignedFile is the handler for time-limited links that accept a file.
+// @Summary      Upload a file through a signed link
+// @Description  Stores the request body as the file of an upload ticket, out of public reach until the ticket is completed. The link is handed out with the ticket and stops working once it expires or if any part of it is changed. The body is streamed to storage, so it may be as large as the size declared on the ticket, but no larger. Only the local storage driver serves these links; other drivers hand out links to the storage service itself.
+// @Tags         Files
+// @Accept       octet-stream
+// @Param        key        path   string  true  "File key"
+// @Param        expires    query  int     true  "Unix time the link expires at"
+// @Param        signature  query  string  true  "Signature of the link"
+// @Success      200  "File stored"
+// @Failure      403  {object}  response.ApiResponse "Invalid or expired link"
+// @Failure      404  {object}  response.ApiResponse "Not found"
+// @Failure      413  {object}  response.ApiResponse "File larger than declared on the ticket"
+// @Router       /files/{key} [put]
+func (h *FileHandler) PutSignedFile(c *fiber.Ctx) error {
+	// Chunked requests have no Content-Length, which the header reports as -1
+	meta := storage.ObjectMeta{ContentType: c.Get(fiber.HeaderContentType), Size: int64(c.Request().Header.ContentLength())}
+	err := h.fileService.PutSignedFile(c.UserContext(), c.Params("key"), c.Query("expires"), c.Query("signature"), requestBody(c), meta)
+	if err != nil {
+		return fileError(c, err, "could not store file")
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// fileError maps a file service error to an HTTP response.
+func fileError(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, storage.ErrInvalidSignature) {
+		return response.Error(c, fiber.StatusForbidden, err)
+	}
+	if errors.Is(err, service.ErrUploadTooLarge) {
+		return response.Error(c, fiber.StatusRequestEntityTooLarge, err)
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return response.Error(c, fiber.StatusNotFound, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FollowHandler struct {
+	followService *service.FollowService
+}
+
+// NewFollowHandler creates a new FollowHandler.
+func NewFollowHandler(followService *service.FollowService) *FollowHandler {
+	return &FollowHandler{followService: followService}
+}
+
+// Follow is the handler for following a user.
+// @Summary      Follow a user
+// @Description  Follows a user, which gives access to their followers-only posts.
+// @Tags         User
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  response.ApiResponse "Successfully followed user"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "User not found"
+// @Router       /users/{id}/follow [post]
+func (h *FollowHandler) Follow(c *fiber.Ctx) error {
+	followeeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.followService.Follow(userID, followeeID); err != nil {
+		if strings.Contains(err.Error(), "yourself") {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, errors.New("user not found"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not follow user"))
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// Unfollow is the handler for unfollowing a user.
+// @Summary      Unfollow a user
+// @Description  Stops following a user.
+// @Tags         User
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  response.ApiResponse "Successfully unfollowed user"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Router       /users/{id}/follow [delete]
+func (h *FollowHandler) Unfollow(c *fiber.Ctx) error {
+	followeeID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.followService.Unfollow(userID, followeeID); err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not unfollow user"))
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+}
+
+// NewModerationHandler creates a new ModerationHandler.
+func NewModerationHandler(moderationService *service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{moderationService: moderationService}
+}
+
+// ReportPostPayload defines the expected JSON for reporting a post.
+type ReportPostPayload struct {
+	Reason string `json:"reason" validate:"required,oneof=spam harassment hate_speech violence sexual_content misinformation other"`
+	Note   string `json:"note" validate:"max=500"`
+}
+
+// ModerationActionPayload defines the expected JSON for acting on a reported post.
+type ModerationActionPayload struct {
+	Action string `json:"action" validate:"required,oneof=dismiss hide delete suspend_author"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ReportPost is the handler for reporting a post.
+// @Summary      Report a post
+// @Description  Reports a post to the moderators. Each user can report a post once. Posts with enough open reports are hidden until reviewed.
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string             true  "Post ID"
+// @Param        payload  body      ReportPostPayload  true  "Report Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.PostReport} "Successfully reported post"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Post not found"
+// @Failure      409      {object}  response.ApiResponse "Already reported"
+// @Router       /posts/{id}/report [post]
+func (h *ModerationHandler) ReportPost(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(ReportPostPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	report, err := h.moderationService.ReportPost(postID, userID, payload.Reason, payload.Note)
+	if err != nil {
+		return moderationError(c, err, "could not report post")
+	}
+
+	return response.Success(c, fiber.StatusCreated, report)
+}
+
+// GetReportQueue is the handler for the moderator queue.
+// @Summary      List reported posts
+// @Description  Lists posts with open reports, grouped by post, most reported first. Moderators only.
+// @Tags         Moderation
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        page   query     int  false  "Page number for pagination" default(1)
+// @Param        limit  query     int  false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.ReportedPost} "Successfully retrieved the queue"
+// @Failure      401    {object}  response.ApiResponse "Unauthorized"
+// @Failure      403    {object}  response.ApiResponse "Forbidden"
+// @Failure      500    {object}  response.ApiResponse "Internal Server Error"
+// @Router       /moderation/reports [get]
+func (h *ModerationHandler) GetReportQueue(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	page, limit := paginationParams(c)
+
+	entries, total, err := h.moderationService.GetReportQueue(userID, page, limit)
+	if err != nil {
+		return moderationError(c, err, "could not retrieve reports")
+	}
+
+	return response.Pagination(c, entries, page, limit, total)
+}
+
+// TakeAction is the handler for acting on a reported post.
+// @Summary      Moderate a post
+// @Description  Dismisses the reports of a post, hides it, deletes it or suspends its author. All open reports of the post are resolved and the action is recorded. Moderators only.
+// @Tags         Moderation
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                   true  "Post ID"
+// @Param        payload  body      ModerationActionPayload  true  "Moderation Action Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.ModerationAction} "Successfully applied action"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Post not found"
+// @Router       /moderation/posts/{id}/actions [post]
+func (h *ModerationHandler) TakeAction(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(ModerationActionPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	action, err := h.moderationService.TakeAction(postID, userID, payload.Action, payload.Reason)
+	if err != nil {
+		return moderationError(c, err, "could not apply moderation action")
+	}
+
+	return response.Success(c, fiber.StatusOK, action)
+}
+
+// moderationError maps a moderation service error to an HTTP response.
+func moderationError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, policy.ErrForbidden):
+		return response.Error(c, fiber.StatusForbidden, err)
+	case strings.Contains(msg, "already reported"):
+		return response.Error(c, fiber.StatusConflict, err)
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+	case strings.Contains(msg, "cannot"), strings.Contains(msg, "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
@@ -0,0 +1,268 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// streamHeartbeatInterval is how often an idle notification stream sends a keep-alive comment,
+// which also lets the server notice clients that went away.
+const streamHeartbeatInterval = 25 * time.Second
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler.
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// NotificationList is a page of notifications along with the caller's unread count.
+type NotificationList struct {
+	Notifications []model.Notification `json:"notifications"`
+	UnreadCount   int64                `json:"unread_count"`
+}
+
+// MarkedRead reports the result of marking all notifications as read.
+type MarkedRead struct {
+	Updated int64 `json:"updated"`
+}
+
+// GetNotifications is the handler for listing the caller's notifications.
+// @Summary      List notifications
+// @Description  Lists the caller's notifications, newest first, along with the number of unread ones.
+// @Tags         Notifications
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        page         query     int   false  "Page number for pagination" default(1)
+// @Param        limit        query     int   false  "Number of items per page" default(10)
+// @Param        unread_only  query     bool  false  "Only list unread notifications"
+// @Success      200          {object}  response.ApiResponse{data=NotificationList} "Successfully retrieved notifications"
+// @Failure      401          {object}  response.ApiResponse "Unauthorized"
+// @Failure      500          {object}  response.ApiResponse "Internal Server Error"
+// @Router       /notifications [get]
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	page, limit := paginationParams(c)
+	unreadOnly := c.QueryBool("unread_only")
+
+	notifications, total, unread, err := h.notificationService.GetNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve notifications"))
+	}
+
+	return response.Pagination(c, NotificationList{Notifications: notifications, UnreadCount: unread}, page, limit, total)
+}
+
+// MarkRead is the handler for marking a notification as read.
+// @Summary      Mark a notification as read
+// @Description  Marks one of the caller's notifications as read. Marking it again keeps the first read time.
+// @Tags         Notifications
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Notification ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Notification} "Successfully marked notification as read"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Notification not found"
+// @Router       /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	notification, err := h.notificationService.MarkRead(userID, id)
+	if err != nil {
+		return notificationError(c, err, "could not mark notification as read")
+	}
+
+	return response.Success(c, fiber.StatusOK, notification)
+}
+
+// MarkAllRead is the handler for marking all notifications as read.
+// @Summary      Mark all notifications as read
+// @Description  Marks every unread notification of the caller as read.
+// @Tags         Notifications
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=MarkedRead} "Successfully marked notifications as read"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	updated, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not mark notifications as read"))
+	}
+
+	return response.Success(c, fiber.StatusOK, MarkedRead{Updated: updated})
+}
+
+// GetPreferences is the handler for reading the caller's notification preferences.
+// @Summary      Get notification preferences
+// @Description  Tells for every notification type whether the caller receives it.
+// @Tags         Notifications
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=map[string]bool} "Successfully retrieved preferences"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /notifications/preferences [get]
+func (h *NotificationHandler) GetPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	preferences, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve preferences"))
+	}
+
+	return response.Success(c, fiber.StatusOK, preferences)
+}
+
+// UpdatePreferences is the handler for changing the caller's notification preferences.
+// @Summary      Update notification preferences
+// @Description  Turns notification types on or off, e.g. {"follow": false}. Types left out keep their setting.
+// @Tags         Notifications
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      map[string]bool  true  "Preferences by notification type"
+// @Success      200      {object}  response.ApiResponse{data=map[string]bool} "Successfully updated preferences"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Router       /notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	var payload map[string]bool
+	if err := c.BodyParser(&payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(userID, payload)
+	if err != nil {
+		return notificationError(c, err, "could not update preferences")
+	}
+
+	return response.Success(c, fiber.StatusOK, preferences)
+}
+
+// Stream is the handler for the live notification stream.
+// @Summary      Stream notifications
+// @Description  Opens a Server-Sent Events stream. A "ready" event carrying the unread count is sent first, then a "notification" event for every new notification.
+// @Tags         Notifications
+// @Produce      text/event-stream
+// @Security     ApiKeyAuth
+// @Success      200  {string}  string "Event stream"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /notifications/stream [get]
+func (h *NotificationHandler) Stream(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	// Subscribe before counting, so nothing created in between is missed
+	notifications, unsubscribe := h.notificationService.Subscribe(userID)
+	unread, err := h.notificationService.CountUnread(userID)
+	if err != nil {
+		unsubscribe()
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not open notification stream"))
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(streamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		if err := writeEvent(w, "ready", "", fiber.Map{"unread_count": unread}); err != nil {
+			return
+		}
+		for {
+			select {
+			case notification, open := <-notifications:
+				if !open {
+					return // Server is shutting down
+				}
+				if err := writeEvent(w, "notification", notification.ID.String(), notification); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return // Client went away
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeEvent sends one Server-Sent Event with a JSON payload and flushes it to the client.
+func writeEvent(w *bufio.Writer, event, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Failed to encode stream event", "event", event, "error", err)
+		return err
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return w.Flush()
+}
+
+// notificationError maps a notification service error to an HTTP response.
+func notificationError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
@@ -2,9 +2,11 @@ package http
 
 import (
 	"errors"
+	"net/url"
 	"strconv"
 	"strings"
 	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
 	"venturo-core/internal/service"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
@@ -28,18 +30,21 @@ type Meta struct {
 }
 
 type PostHandler struct {
-	postService *service.PostService
+	postService      *service.PostService
+	analyticsService *service.AnalyticsService
 }
 
 // NewPostHandler creates a new PostHandler.
-func NewPostHandler(postService *service.PostService) *PostHandler {
-	return &PostHandler{postService: postService}
+func NewPostHandler(postService *service.PostService, analyticsService *service.AnalyticsService) *PostHandler {
+	return &PostHandler{postService: postService, analyticsService: analyticsService}
 }
 
 // CreatePostPayload defines the expected JSON for creating a post.
 type CreatePostPayload struct {
-	Title string `json:"title" validate:"required,min=5"`
-	Body  string `json:"body"`
+	Title      string   `json:"title" validate:"required,min=5"`
+	Body       string   `json:"body" validate:"max=65535"`
+	Visibility string   `json:"visibility" validate:"omitempty,oneof=public unlisted private followers"`
+	Tags       []string `json:"tags" validate:"max=10,dive,max=50"`
 }
 
 // CreatePost is the handler for creating a new post.
@@ -70,7 +75,7 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 		return response.ValidationError(c, errs)
 	}
 
-	post, err := h.postService.CreatePost(userID, payload.Title, payload.Body)
+	post, err := h.postService.CreatePost(userID, payload.Title, payload.Body, payload.Visibility, payload.Tags)
 	if err != nil {
 		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not create post"))
 	}
@@ -80,45 +85,143 @@ func (h *PostHandler) CreatePost(c *fiber.Ctx) error {
 
 // GetAllPosts now handles pagination and returns a structured response.
 // @Summary      Get all posts
-// @Description  Retrieves a paginated list of all posts.
+// @Description  Retrieves a paginated list of the posts the caller may see. Anonymous callers only get public posts.
 // @Tags         Posts
 // @Produce      json
+// @Security     ApiKeyAuth
 // @Param        page   query     int  false  "Page number for pagination" default(1)
-// @Param        limit  query     int  false  "Number of items per page" default(10)
+// @Param        limit  query     int     false  "Number of items per page" default(10)
+// @Param        sort   query     string  false  "Sort order" Enums(latest, popular) default(latest)
+// @Param        author query     string  false  "Only list posts by this author ID"
+// @Param        tag    query     string  false  "Only list posts with this tag"
+// @Param        format query     string  false  "Body format" Enums(markdown, html, plain) default(markdown)
 // @Success      200    {object}  response.ApiResponse{data=[]model.Post} "Successfully retrieved posts"
+// @Failure      400    {object}  response.ApiResponse "Bad Request"
 // @Failure      500    {object}  response.ApiResponse "Internal Server Error"
 // @Router       /posts [get]
 func (h *PostHandler) GetAllPosts(c *fiber.Ctx) error {
-	// 1. Parse query parameters for pagination
-	page, err := strconv.Atoi(c.Query("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
+	// 1. Parse query parameters for pagination and sorting
+	page, limit := paginationParams(c)
+
+	sort := c.Query("sort", model.PostSortLatest)
+	if sort != model.PostSortLatest && sort != model.PostSortPopular {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid sort order"))
 	}
 
-	limit, err := strconv.Atoi(c.Query("limit", "10"))
-	if err != nil || limit < 1 {
-		limit = 10
+	var authorID uuid.UUID
+	if author := c.Query("author"); author != "" {
+		var err error
+		if authorID, err = uuid.Parse(author); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid author ID format"))
+		}
 	}
-	if limit > 100 { // Set a max limit
-		limit = 100
+
+	var tag string
+	if c.Query("tag") != "" {
+		if tag = model.NormalizeTag(c.Query("tag")); tag == "" {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid tag"))
+		}
+	}
+
+	format, ok := formatParam(c)
+	if !ok {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid format"))
 	}
 
+	// The optional auth middleware only sets the user ID for authenticated callers
+	viewerID, _ := c.Locals("current_user_id").(uuid.UUID)
+
 	// 2. Call the service to get paginated data and total count
-	posts, total, err := h.postService.GetAllPosts(page, limit)
+	query := model.PostQuery{Page: page, Limit: limit, Sort: sort, AuthorID: authorID, Tag: tag, ViewerID: viewerID}
+	posts, total, err := h.postService.GetAllPosts(query)
 	if err != nil {
 		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve posts"))
 	}
 
+	for i := range posts {
+		if err := posts[i].ApplyFormat(format); err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, errors.New("could not render posts"))
+		}
+	}
+
 	return response.Pagination(c, posts, page, limit, total)
 }
 
+// GetTrash is the handler for listing the caller's trashed posts.
+// @Summary      List trashed posts
+// @Description  Retrieves a paginated list of the authenticated user's own deleted posts.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        page   query     int  false  "Page number for pagination" default(1)
+// @Param        limit  query     int  false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.Post} "Successfully retrieved trashed posts"
+// @Failure      401    {object}  response.ApiResponse "Unauthorized"
+// @Failure      500    {object}  response.ApiResponse "Internal Server Error"
+// @Router       /posts/trash [get]
+func (h *PostHandler) GetTrash(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	page, limit := paginationParams(c)
+
+	posts, total, err := h.postService.GetTrash(userID, page, limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve trashed posts"))
+	}
+
+	return response.Pagination(c, posts, page, limit, total)
+}
+
+// RestorePost is the handler for taking a post out of the trash.
+// @Summary      Restore a trashed post
+// @Description  Restores a deleted post. Only the author and co-owners can restore it.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Post ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Post} "Successfully restored post"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      403  {object}  response.ApiResponse "Forbidden"
+// @Failure      404  {object}  response.ApiResponse "Post not found in trash"
+// @Router       /posts/{id}/restore [post]
+func (h *PostHandler) RestorePost(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	post, err := h.postService.RestorePost(postID, userID)
+	if err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			return response.Error(c, fiber.StatusForbidden, err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, errors.New("post not found in trash"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not restore post"))
+	}
+
+	return response.Success(c, fiber.StatusOK, post)
+}
+
 // GetPostByID is the handler for retrieving a single post by its ID.
 // @Summary      Get a single post
-// @Description  Retrieves a single post by its unique ID.
+// @Description  Retrieves a single post by its unique ID, with reaction counts. Posts the caller may not see are reported as not found. Authenticated callers also get their own reactions.
 // @Tags         Posts
 // @Produce      json
-// @Param        id   path      string  true  "Post ID"
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true   "Post ID"
+// @Param        format  query     string  false  "Body format" Enums(markdown, html, plain) default(markdown)
 // @Success      200  {object}  response.ApiResponse{data=model.Post} "Successfully retrieved post"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
 // @Failure      404  {object}  response.ApiResponse "Post not found"
 // @Router       /posts/{id} [get]
 func (h *PostHandler) GetPostByID(c *fiber.Ctx) error {
@@ -129,17 +232,72 @@ func (h *PostHandler) GetPostByID(c *fiber.Ctx) error {
 		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
 	}
 
-	post, err := h.postService.GetPostByID(id)
+	format, ok := formatParam(c)
+	if !ok {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid format"))
+	}
+
+	// The optional auth middleware only sets the user ID for authenticated callers
+	viewerID, _ := c.Locals("current_user_id").(uuid.UUID)
+
+	post, err := h.postService.GetPostByID(id, viewerID)
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+	}
+
+	if err := post.ApplyFormat(format); err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not render post"))
+	}
+
+	h.analyticsService.RecordView(post, viewerOf(c, viewerID))
+	return response.Success(c, fiber.StatusOK, post)
+}
+
+// GetPostBySlug is the handler for retrieving a single post by its slug.
+// @Summary      Get a single post by slug
+// @Description  Retrieves a post by its current slug. Slugs retired by a title change answer with a 301 redirect to the current one.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        slug    path      string  true   "Post slug"
+// @Param        format  query     string  false  "Body format" Enums(markdown, html, plain) default(markdown)
+// @Success      200  {object}  response.ApiResponse{data=model.Post} "Successfully retrieved post"
+// @Success      301  "Moved permanently to the post's current slug"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Post not found"
+// @Router       /posts/by-slug/{slug} [get]
+func (h *PostHandler) GetPostBySlug(c *fiber.Ctx) error {
+	format, ok := formatParam(c)
+	if !ok {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid format"))
+	}
+
+	viewerID, _ := c.Locals("current_user_id").(uuid.UUID)
+
+	post, currentSlug, err := h.postService.GetPostBySlug(c.Params("slug"), viewerID)
 	if err != nil {
 		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
 	}
 
+	if currentSlug != "" {
+		location := "/api/v1/posts/by-slug/" + url.PathEscape(currentSlug)
+		if query := string(c.Request().URI().QueryString()); query != "" {
+			location += "?" + query
+		}
+		return c.Redirect(location, fiber.StatusMovedPermanently)
+	}
+
+	if err := post.ApplyFormat(format); err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not render post"))
+	}
+
+	h.analyticsService.RecordView(post, viewerOf(c, viewerID))
 	return response.Success(c, fiber.StatusOK, post)
 }
 
 // DeletePost is the handler for deleting a post.
 // @Summary      Delete a post
-// @Description  Deletes a post. Only the author can delete their post.
+// @Description  Moves a post to the trash. Only the author and co-owners can delete it.
 // @Tags         Posts
 // @Produce      json
 // @Security     ApiKeyAuth
@@ -165,7 +323,7 @@ func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
 	// Call the service to delete the post
 	err = h.postService.DeletePost(postID, userID)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, policy.ErrForbidden) {
 			return response.Error(c, fiber.StatusForbidden, err)
 		}
 		if strings.Contains(err.Error(), "not found") {
@@ -180,7 +338,7 @@ func (h *PostHandler) DeletePost(c *fiber.Ctx) error {
 
 // UpdatePost is the handler for updating a post.
 // @Summary      Update a post
-// @Description  Updates a post. Only the author can update their post.
+// @Description  Updates a post. The author, co-owners and editors can change the content; only the author and co-owners can change the visibility. Omitting tags leaves them unchanged, while an empty list removes them.
 // @Tags         Posts
 // @Accept       json
 // @Produce      json
@@ -214,9 +372,9 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 		return response.ValidationError(c, errs)
 	}
 
-	updatedPost, err := h.postService.UpdatePost(postID, userID, payload.Title, payload.Body)
+	updatedPost, err := h.postService.UpdatePost(postID, userID, payload.Title, payload.Body, payload.Visibility, payload.Tags)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") {
+		if errors.Is(err, policy.ErrForbidden) {
 			return response.Error(c, fiber.StatusForbidden, err)
 		}
 		if strings.Contains(err.Error(), "not found") {
@@ -227,3 +385,177 @@ func (h *PostHandler) UpdatePost(c *fiber.Ctx) error {
 
 	return response.Success(c, fiber.StatusOK, updatedPost)
 }
+
+// GetRevisions is the handler for listing the revision history of a post.
+// @Summary      List post revisions
+// @Description  Retrieves every revision of a post, newest first. Only the author, co-owners and editors can view the history.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Post ID"
+// @Success      200  {object}  response.ApiResponse{data=[]model.PostRevision} "Successfully retrieved revisions"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      403  {object}  response.ApiResponse "Forbidden"
+// @Failure      404  {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/revisions [get]
+func (h *PostHandler) GetRevisions(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	revisions, err := h.postService.GetRevisions(postID, userID)
+	if err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			return response.Error(c, fiber.StatusForbidden, err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve revisions"))
+	}
+
+	return response.Success(c, fiber.StatusOK, revisions)
+}
+
+// DiffRevisions is the handler for comparing two revisions of a post.
+// @Summary      Diff two post revisions
+// @Description  Returns a line-based diff of the title and body between two revisions. Only the author, co-owners and editors can view it.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      string  true  "Post ID"
+// @Param        from  query     int     true  "Revision to compare from"
+// @Param        to    query     int     true  "Revision to compare to"
+// @Success      200   {object}  response.ApiResponse{data=service.RevisionDiff} "Successfully computed diff"
+// @Failure      400   {object}  response.ApiResponse "Bad Request"
+// @Failure      401   {object}  response.ApiResponse "Unauthorized"
+// @Failure      403   {object}  response.ApiResponse "Forbidden"
+// @Failure      404   {object}  response.ApiResponse "Post or revision not found"
+// @Router       /posts/{id}/revisions/diff [get]
+func (h *PostHandler) DiffRevisions(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid 'from' revision"))
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to < 1 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid 'to' revision"))
+	}
+
+	diff, err := h.postService.DiffRevisions(postID, userID, from, to)
+	if err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			return response.Error(c, fiber.StatusForbidden, err)
+		}
+		if strings.Contains(err.Error(), "revision not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not compute diff"))
+	}
+
+	return response.Success(c, fiber.StatusOK, diff)
+}
+
+// RestoreRevision is the handler for restoring an old revision of a post.
+// @Summary      Restore a post revision
+// @Description  Copies the content of an old revision back onto the post, recording the restore as a new revision.
+// @Tags         Posts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Post ID"
+// @Param        rev  path      int     true  "Revision number"
+// @Success      200  {object}  response.ApiResponse{data=model.Post} "Successfully restored revision"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      403  {object}  response.ApiResponse "Forbidden"
+// @Failure      404  {object}  response.ApiResponse "Post or revision not found"
+// @Router       /posts/{id}/revisions/{rev}/restore [post]
+func (h *PostHandler) RestoreRevision(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	revision, err := strconv.Atoi(c.Params("rev"))
+	if err != nil || revision < 1 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid revision number"))
+	}
+
+	post, err := h.postService.RestoreRevision(postID, userID, revision)
+	if err != nil {
+		if errors.Is(err, policy.ErrForbidden) {
+			return response.Error(c, fiber.StatusForbidden, err)
+		}
+		if strings.Contains(err.Error(), "revision not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not restore revision"))
+	}
+
+	return response.Success(c, fiber.StatusOK, post)
+}
+
+// paginationParams parses the page and limit query parameters, applying defaults and a max limit.
+func paginationParams(c *fiber.Ctx) (int, int) {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 100 { // Set a max limit
+		limit = 100
+	}
+
+	return page, limit
+}
+
+// viewerOf describes the caller of a read endpoint for view counting.
+func viewerOf(c *fiber.Ctx, viewerID uuid.UUID) service.Viewer {
+	return service.Viewer{
+		UserID:    viewerID,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		Referrer:  c.Get(fiber.HeaderReferer),
+	}
+}
+
+// formatParam reads the optional body format query parameter of the read endpoints.
+func formatParam(c *fiber.Ctx) (string, bool) {
+	format := c.Query("format", model.PostFormatMarkdown)
+	switch format {
+	case model.PostFormatMarkdown, model.PostFormatHTML, model.PostFormatPlain:
+		return format, true
+	}
+	return "", false
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ReactionHandler struct {
+	reactionService *service.ReactionService
+}
+
+// NewReactionHandler creates a new ReactionHandler.
+func NewReactionHandler(reactionService *service.ReactionService) *ReactionHandler {
+	return &ReactionHandler{reactionService: reactionService}
+}
+
+// React is the handler for adding a reaction to a post.
+// @Summary      React to a post
+// @Description  Adds the authenticated user's reaction of the given type. Each user can react once per type.
+// @Tags         Reactions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      string  true  "Post ID"
+// @Param        type  path      string  true  "Reaction type"
+// @Success      200   {object}  response.ApiResponse{data=service.ReactionSummary} "Successfully reacted"
+// @Failure      400   {object}  response.ApiResponse "Bad Request"
+// @Failure      401   {object}  response.ApiResponse "Unauthorized"
+// @Failure      404   {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/reactions/{type} [put]
+func (h *ReactionHandler) React(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	summary, err := h.reactionService.React(postID, userID, c.Params("type"))
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, summary)
+}
+
+// Unreact is the handler for removing a reaction from a post.
+// @Summary      Remove a reaction
+// @Description  Removes the authenticated user's reaction of the given type.
+// @Tags         Reactions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id    path      string  true  "Post ID"
+// @Param        type  path      string  true  "Reaction type"
+// @Success      200   {object}  response.ApiResponse{data=service.ReactionSummary} "Successfully removed reaction"
+// @Failure      400   {object}  response.ApiResponse "Bad Request"
+// @Failure      401   {object}  response.ApiResponse "Unauthorized"
+// @Failure      404   {object}  response.ApiResponse "Post not found"
+// @Router       /posts/{id}/reactions/{type} [delete]
+func (h *ReactionHandler) Unreact(c *fiber.Ctx) error {
+	postID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	summary, err := h.reactionService.Unreact(postID, userID, c.Params("type"))
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, summary)
+}
+
+// reactionError maps a reaction service error to an HTTP response.
+func reactionError(c *fiber.Ctx, err error) error {
+	if strings.Contains(err.Error(), "invalid reaction type") {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New("could not update reaction"))
+}
@@ -0,0 +1,279 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// tusVersion is the version of the tus resumable upload protocol the upload routes speak.
+const tusVersion = "1.0.0"
+
+// tusExtensions lists the tus extensions the upload routes support.
+const tusExtensions = "creation,termination,expiration"
+
+// tusChunkContentType is the content type of the chunks sent with PATCH requests.
+const tusChunkContentType = "application/offset+octet-stream"
+
+type ResumableUploadHandler struct {
+	resumableUploadService *service.ResumableUploadService
+}
+
+// NewResumableUploadHandler creates a new ResumableUploadHandler.
+func NewResumableUploadHandler(resumableUploadService *service.ResumableUploadService) *ResumableUploadHandler {
+	return &ResumableUploadHandler{resumableUploadService: resumableUploadService}
+}
+
+// TusResumable is the middleware of the upload routes. It adds the Tus-Resumable header to every
+// response, and turns away tus requests made with a protocol version the server does not speak.
+func (h *ResumableUploadHandler) TusResumable(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+
+	// OPTIONS is how clients find out the version, and GET is not part of the protocol
+	method := c.Method()
+	if method != fiber.MethodOptions && method != fiber.MethodGet && c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return response.Error(c, fiber.StatusPreconditionFailed, errors.New("unsupported tus version"))
+	}
+	return c.Next()
+}
+
+// GetServerOptions is the handler for discovering what the upload routes support.
+// @Summary      Describe resumable uploads
+// @Description  Reports the tus protocol version, the supported extensions and the largest upload accepted, in the Tus-Version, Tus-Extension and Tus-Max-Size headers.
+// @Tags         Uploads
+// @Success      204  "Supported protocol, in the headers"
+// @Router       /uploads [options]
+func (h *ResumableUploadHandler) GetServerOptions(c *fiber.Ctx) error {
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(h.resumableUploadService.MaxSize(), 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateUpload is the handler for starting a resumable upload.
+// @Summary      Start a resumable upload
+// @Description  Starts a tus upload of Upload-Length bytes and returns its address in the Location header. The filename and filetype entries of Upload-Metadata name the file and set its content type. Unfinished uploads expire at the time in Upload-Expires, which moves back with every chunk.
+// @Tags         Uploads
+// @Security     ApiKeyAuth
+// @Param        Tus-Resumable    header  string  true   "Protocol version, 1.0.0"
+// @Param        Upload-Length    header  int     true   "Size of the file in bytes"
+// @Param        Upload-Metadata  header  string  false  "Comma-separated keys with base64-encoded values"
+// @Success      201  "Upload created; see the Location and Upload-Expires headers"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      412  {object}  response.ApiResponse "Unsupported tus version"
+// @Failure      413  {object}  response.ApiResponse "File too large"
+// @Router       /uploads [post]
+func (h *ResumableUploadHandler) CreateUpload(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if c.Get("Upload-Defer-Length") != "" {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("deferred upload length is not supported"))
+	}
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid Upload-Length header"))
+	}
+
+	upload, err := h.resumableUploadService.CreateUpload(userID, length, c.Get("Upload-Metadata"))
+	if err != nil {
+		return resumableUploadError(c, err, "could not create upload")
+	}
+
+	c.Set(fiber.HeaderLocation, h.resumableUploadService.UploadURL(upload.ID))
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// GetUploadOffset is the handler for finding out where to resume an upload.
+// @Summary      Get the offset of a resumable upload
+// @Description  Reports the bytes received so far in Upload-Offset, along with Upload-Length, Upload-Metadata and, while unfinished, Upload-Expires.
+// @Tags         Uploads
+// @Security     ApiKeyAuth
+// @Param        Tus-Resumable  header  string  true  "Protocol version, 1.0.0"
+// @Param        id             path    string  true  "Upload ID"
+// @Success      200  "Upload state, in the headers"
+// @Failure      401  "Unauthorized"
+// @Failure      404  "Upload not found"
+// @Failure      410  "Upload expired"
+// @Router       /uploads/{id} [head]
+func (h *ResumableUploadHandler) GetUploadOffset(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("upload not found"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	upload, err := h.resumableUploadService.GetUpload(id, userID)
+	if err != nil {
+		return resumableUploadError(c, err, "could not retrieve upload")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Set("Upload-Metadata", upload.Metadata)
+	}
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// GetUpload is the handler for checking on a resumable upload.
+// @Summary      Get a resumable upload
+// @Description  Returns the upload's progress and status: "uploading" while chunks arrive, then "local" once the file is complete, "cloud" once it is in storage, when its url is set, "failed" if storing it gave up, or "quarantined" if the malware scanner flagged the file.
+// @Tags         Uploads
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Upload ID"
+// @Success      200  {object}  response.ApiResponse{data=model.ResumableUpload} "Successfully retrieved upload"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Failure      410  {object}  response.ApiResponse "Upload expired"
+// @Router       /uploads/{id} [get]
+func (h *ResumableUploadHandler) GetUpload(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("upload not found"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	upload, err := h.resumableUploadService.GetUpload(id, userID)
+	if err != nil {
+		return resumableUploadError(c, err, "could not retrieve upload")
+	}
+	return response.Success(c, fiber.StatusOK, upload)
+}
+
+// WriteChunk is the handler for sending the next chunk of a resumable upload.
+// @Summary      Send a chunk of a resumable upload
+// @Description  Writes the request body at Upload-Offset, which must equal the bytes received so far, and returns the new offset. The chunk that completes the file hands it over to be stored. Chunks are streamed to disk, so a chunk may be as large as the rest of the upload; its Content-Length is checked against that before it is read.
+// @Tags         Uploads
+// @Accept       application/offset+octet-stream
+// @Security     ApiKeyAuth
+// @Param        Tus-Resumable  header  string  true  "Protocol version, 1.0.0"
+// @Param        Upload-Offset  header  int     true  "Bytes received so far"
+// @Param        id             path    string  true  "Upload ID"
+// @Success      204  "Chunk written; see the Upload-Offset and Upload-Expires headers"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Failure      409  {object}  response.ApiResponse "Offset does not match the upload"
+// @Failure      410  {object}  response.ApiResponse "Upload expired"
+// @Failure      413  {object}  response.ApiResponse "Chunk goes past the upload length"
+// @Failure      415  {object}  response.ApiResponse "Wrong content type"
+// @Failure      423  {object}  response.ApiResponse "Another chunk of the upload is still arriving"
+// @Router       /uploads/{id} [patch]
+func (h *ResumableUploadHandler) WriteChunk(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("upload not found"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if c.Get(fiber.HeaderContentType) != tusChunkContentType {
+		return response.Error(c, fiber.StatusUnsupportedMediaType, errors.New("content type must be "+tusChunkContentType))
+	}
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid Upload-Offset header"))
+	}
+
+	// Chunked requests have no Content-Length, which the header reports as -1
+	size := int64(c.Request().Header.ContentLength())
+	upload, err := h.resumableUploadService.WriteChunk(id, userID, offset, size, requestBody(c))
+	if err != nil {
+		return resumableUploadError(c, err, "could not write chunk")
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	setUploadExpires(c, upload)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TerminateUpload is the handler for abandoning a resumable upload.
+// @Summary      Terminate a resumable upload
+// @Description  Deletes the upload and the file received so far, or the stored file if it was finished.
+// @Tags         Uploads
+// @Security     ApiKeyAuth
+// @Param        Tus-Resumable  header  string  true  "Protocol version, 1.0.0"
+// @Param        id             path    string  true  "Upload ID"
+// @Success      204  "Successfully terminated upload"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Router       /uploads/{id} [delete]
+func (h *ResumableUploadHandler) TerminateUpload(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("upload not found"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.resumableUploadService.TerminateUpload(c.UserContext(), id, userID); err != nil {
+		return resumableUploadError(c, err, "could not terminate upload")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// setUploadExpires tells the client until when an unfinished upload can be resumed.
+func setUploadExpires(c *fiber.Ctx, upload *model.ResumableUpload) {
+	if upload.Status == model.ResumableUploadUploading {
+		c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// requestBody returns the request body as a stream, so large bodies are never held in memory.
+func requestBody(c *fiber.Ctx) io.Reader {
+	if stream := c.Context().RequestBodyStream(); stream != nil {
+		return stream
+	}
+	return bytes.NewReader(c.Body())
+}
+
+// resumableUploadError maps a resumable upload service error to an HTTP response.
+func resumableUploadError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, service.ErrUploadOffsetMismatch):
+		return response.Error(c, fiber.StatusConflict, err)
+	case errors.Is(err, service.ErrUploadTooLarge):
+		return response.Error(c, fiber.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, service.ErrUploadExpired):
+		return response.Error(c, fiber.StatusGone, err)
+	case errors.Is(err, service.ErrUploadBusy):
+		return response.Error(c, fiber.StatusLocked, err)
+	case strings.Contains(err.Error(), "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(err.Error(), "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
@@ -0,0 +1,321 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SeriesHandler struct {
+	seriesService *service.SeriesService
+}
+
+// NewSeriesHandler creates a new SeriesHandler.
+func NewSeriesHandler(seriesService *service.SeriesService) *SeriesHandler {
+	return &SeriesHandler{seriesService: seriesService}
+}
+
+// SeriesPayload defines the expected JSON for creating or updating a series.
+type SeriesPayload struct {
+	Title       string `json:"title" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=5000"`
+}
+
+// AddSeriesPostPayload defines the expected JSON for adding a post to a series.
+type AddSeriesPostPayload struct {
+	PostID uuid.UUID `json:"post_id" validate:"required"`
+}
+
+// ReorderSeriesPayload defines the expected JSON for reordering the posts of a series.
+type ReorderSeriesPayload struct {
+	PostIDs []uuid.UUID `json:"post_ids" validate:"required"`
+}
+
+// CreateSeries is the handler for creating a series.
+// @Summary      Create a series
+// @Description  Creates a new, empty series of posts owned by the caller.
+// @Tags         Series
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      SeriesPayload  true  "Series Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.Series} "Successfully created series"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Router       /series [post]
+func (h *SeriesHandler) CreateSeries(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(SeriesPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	series, err := h.seriesService.CreateSeries(userID, payload.Title, payload.Description)
+	if err != nil {
+		return seriesError(c, err, "could not create series")
+	}
+
+	return response.Success(c, fiber.StatusCreated, series)
+}
+
+// GetSeries is the handler for reading a series.
+// @Summary      Get a series
+// @Description  Retrieves a series with its posts in reading order. Posts the caller may not read are left out.
+// @Tags         Series
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Series ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Series} "Successfully retrieved series"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Series not found"
+// @Router       /series/{id} [get]
+func (h *SeriesHandler) GetSeries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	format, ok := formatParam(c)
+	if !ok {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid format"))
+	}
+
+	// The optional auth middleware only sets the user ID for authenticated callers
+	viewerID, _ := c.Locals("current_user_id").(uuid.UUID)
+
+	series, err := h.seriesService.GetSeries(id, viewerID)
+	if err != nil {
+		return seriesError(c, err, "could not retrieve series")
+	}
+
+	for i := range series.Posts {
+		if err := series.Posts[i].ApplyFormat(format); err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, errors.New("could not render posts"))
+		}
+	}
+
+	return response.Success(c, fiber.StatusOK, series)
+}
+
+// UpdateSeries is the handler for updating a series.
+// @Summary      Update a series
+// @Description  Changes the title and description of one of the caller's series.
+// @Tags         Series
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string         true  "Series ID"
+// @Param        payload  body      SeriesPayload  true  "Series Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.Series} "Successfully updated series"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Series not found"
+// @Router       /series/{id} [put]
+func (h *SeriesHandler) UpdateSeries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(SeriesPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	series, err := h.seriesService.UpdateSeries(userID, id, payload.Title, payload.Description)
+	if err != nil {
+		return seriesError(c, err, "could not update series")
+	}
+
+	return response.Success(c, fiber.StatusOK, series)
+}
+
+// DeleteSeries is the handler for deleting a series.
+// @Summary      Delete a series
+// @Description  Deletes one of the caller's series. Its posts are kept.
+// @Tags         Series
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Series ID"
+// @Success      200  {object}  response.ApiResponse "Successfully deleted series"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      403  {object}  response.ApiResponse "Forbidden"
+// @Failure      404  {object}  response.ApiResponse "Series not found"
+// @Router       /series/{id} [delete]
+func (h *SeriesHandler) DeleteSeries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.seriesService.DeleteSeries(userID, id); err != nil {
+		return seriesError(c, err, "could not delete series")
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// AddPost is the handler for adding a post to a series.
+// @Summary      Add a post to a series
+// @Description  Appends one of the caller's posts to the end of their series. A post can only belong to one series.
+// @Tags         Series
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                true  "Series ID"
+// @Param        payload  body      AddSeriesPostPayload  true  "Series Post Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.Series} "Successfully added post"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Series or post not found"
+// @Failure      409      {object}  response.ApiResponse "Post already in a series"
+// @Router       /series/{id}/posts [post]
+func (h *SeriesHandler) AddPost(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(AddSeriesPostPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	series, err := h.seriesService.AddPost(userID, id, payload.PostID)
+	if err != nil {
+		return seriesError(c, err, "could not add post to series")
+	}
+
+	return response.Success(c, fiber.StatusOK, series)
+}
+
+// ReorderPosts is the handler for reordering the posts of a series.
+// @Summary      Reorder a series
+// @Description  Puts the posts of one of the caller's series in the given order. Every post of the series must be listed exactly once.
+// @Tags         Series
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                true  "Series ID"
+// @Param        payload  body      ReorderSeriesPayload  true  "Order Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.Series} "Successfully reordered series"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Series not found"
+// @Router       /series/{id}/posts/order [put]
+func (h *SeriesHandler) ReorderPosts(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(ReorderSeriesPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	series, err := h.seriesService.ReorderPosts(userID, id, payload.PostIDs)
+	if err != nil {
+		return seriesError(c, err, "could not reorder series")
+	}
+
+	return response.Success(c, fiber.StatusOK, series)
+}
+
+// RemovePost is the handler for removing a post from a series.
+// @Summary      Remove a post from a series
+// @Description  Takes a post out of one of the caller's series. The post itself is kept.
+// @Tags         Series
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id      path      string  true  "Series ID"
+// @Param        postId  path      string  true  "Post ID"
+// @Success      200     {object}  response.ApiResponse "Successfully removed post"
+// @Failure      400     {object}  response.ApiResponse "Bad Request"
+// @Failure      401     {object}  response.ApiResponse "Unauthorized"
+// @Failure      403     {object}  response.ApiResponse "Forbidden"
+// @Failure      404     {object}  response.ApiResponse "Series or post not found"
+// @Router       /series/{id}/posts/{postId} [delete]
+func (h *SeriesHandler) RemovePost(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	postID, err := uuid.Parse(c.Params("postId"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	if err := h.seriesService.RemovePost(userID, id, postID); err != nil {
+		return seriesError(c, err, "could not remove post from series")
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// seriesError maps a series service error to an HTTP response.
+func seriesError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, policy.ErrForbidden):
+		return response.Error(c, fiber.StatusForbidden, err)
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "already"):
+		return response.Error(c, fiber.StatusConflict, err)
+	case strings.Contains(msg, "invalid"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type UploadTicketHandler struct {
+	uploadTicketService *service.UploadTicketService
+}
+
+// NewUploadTicketHandler creates a new UploadTicketHandler.
+func NewUploadTicketHandler(uploadTicketService *service.UploadTicketService) *UploadTicketHandler {
+	return &UploadTicketHandler{uploadTicketService: uploadTicketService}
+}
+
+// CreateUploadTicketPayload defines the expected JSON for requesting an upload ticket.
+type CreateUploadTicketPayload struct {
+	Purpose        string     `json:"purpose" validate:"required,oneof=avatar attachment"`
+	PostID         *uuid.UUID `json:"post_id"`
+	FileName       string     `json:"file_name" validate:"required,max=255"`
+	ContentType    string     `json:"content_type" validate:"max=100"`
+	Size           int64      `json:"size" validate:"required,gt=0"`
+	ChecksumSHA256 string     `json:"checksum_sha256" validate:"required,len=64,hexadecimal"`
+}
+
+// CompleteUploadTicketPayload defines the expected JSON for completing an upload ticket.
+type CompleteUploadTicketPayload struct {
+	AltText string `json:"alt_text" validate:"max=500"`
+}
+
+// CreateTicket is the handler for requesting a direct-to-storage upload.
+// @Summary      Request an upload ticket
+// @Description  Issues a ticket and a signed upload_url the file is sent to with a PUT request, straight to storage. The declared size and SHA-256 checksum are checked when the ticket is completed. Attachment tickets need a post_id of a post the user may edit. The link stops working at expires_at, and tickets that are never completed are deleted along with their file.
+// @Tags         Uploads
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CreateUploadTicketPayload  true  "Upload Ticket Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.UploadTicket} "Successfully issued ticket"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Post not found"
+// @Router       /upload-tickets [post]
+func (h *UploadTicketHandler) CreateTicket(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(CreateUploadTicketPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	ticket, err := h.uploadTicketService.CreateTicket(c.UserContext(), userID, &model.UploadTicket{
+		Purpose:        payload.Purpose,
+		PostID:         payload.PostID,
+		OriginalName:   payload.FileName,
+		ContentType:    payload.ContentType,
+		Size:           payload.Size,
+		ChecksumSHA256: payload.ChecksumSHA256,
+	})
+	if err != nil {
+		return uploadTicketError(c, err, "could not issue upload ticket")
+	}
+
+	return response.Success(c, fiber.StatusCreated, ticket)
+}
+
+// CompleteTicket is the handler for finishing a direct-to-storage upload.
+// @Summary      Complete an upload ticket
+// @Description  Checks that the file sent to the ticket's upload_url is in storage with the declared size and checksum, then links it. An attachment is added to the end of the post and returned with status "local", then moves to "cloud" once the malware scanner has passed the file, or to "quarantined" if it flags it; an avatar is processed like one sent with the profile and the updated user is returned. The ticket cannot be used again.
+// @Tags         Uploads
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                       true   "Ticket ID"
+// @Param        payload  body      CompleteUploadTicketPayload  false  "Alt text of an attachment"
+// @Success      200      {object}  response.ApiResponse{data=model.UploadTicket} "Successfully completed ticket"
+// @Failure      400      {object}  response.ApiResponse "File missing or not as declared"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Forbidden"
+// @Failure      404      {object}  response.ApiResponse "Ticket or post not found"
+// @Failure      410      {object}  response.ApiResponse "Ticket expired"
+// @Router       /upload-tickets/{id}/complete [post]
+func (h *UploadTicketHandler) CompleteTicket(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(CompleteUploadTicketPayload)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(payload); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+		}
+		if errs := validator.ValidateStruct(payload); errs != nil {
+			return response.ValidationError(c, errs)
+		}
+	}
+
+	ticket, err := h.uploadTicketService.CompleteTicket(c.UserContext(), id, userID, payload.AltText)
+	if err != nil {
+		return uploadTicketError(c, err, "could not complete upload ticket")
+	}
+
+	return response.Success(c, fiber.StatusOK, ticket)
+}
+
+// uploadTicketError maps an upload ticket service error to an HTTP response.
+func uploadTicketError(c *fiber.Ctx, err error, fallback string) error {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, policy.ErrForbidden):
+		return response.Error(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrUploadTicketExpired):
+		return response.Error(c, fiber.StatusGone, err)
+	case strings.Contains(msg, "upload ticket not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case strings.Contains(msg, "not found"):
+		return response.Error(c, fiber.StatusNotFound, errors.New("post not found"))
+	case strings.Contains(msg, "invalid"), strings.Contains(msg, "alt text"):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	return response.Error(c, fiber.StatusInternalServerError, errors.New(fallback))
+}
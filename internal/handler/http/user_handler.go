@@ -2,6 +2,7 @@ package http
 
 import (
 	"errors"
+	"strings"
 	"venturo-core/internal/service"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
@@ -44,9 +45,14 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 	return response.Success(c, fiber.StatusOK, user)
 }
 
-// UpdateProfile correctly handles both form values and file uploads with validation.
+// UpdateProfilePayload defines the expected form values for updating the profile.
+type UpdateProfilePayload struct {
+	Name string `json:"name" validate:"required,min=2"`
+}
+
+// UpdateProfile is the handler for updating the user's profile from form values and an avatar file.
 // @Summary      Update User Profile
-// @Description  Updates the name and/or avatar of the currently authenticated user.
+// @Description  Updates the name and/or avatar of the currently authenticated user. The avatar must be a JPEG, PNG, GIF or WebP image; its content is checked, whatever its file name says. It is cropped to a square, stripped of metadata such as EXIF and GPS data, and stored in several sizes, listed under avatar_urls once they are in storage.
 // @Tags         User
 // @Accept       multipart/form-data
 // @Produce      json
@@ -57,11 +63,6 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 // @Failure      400  {object}  response.ApiResponse "Bad Request"
 // @Failure      401  {object}  response.ApiResponse "Unauthorized"
 // @Router       /profile [put]
-type UpdateProfilePayload struct {
-	Name string `json:"name" validate:"required,min=2"`
-}
-
-// UpdateProfile is the handler for updating the user's profile.
 func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 	// Retrieve the user ID from the locals
 	userID, ok := c.Locals("current_user_id").(uuid.UUID)
@@ -86,6 +87,9 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 
 	updatedUser, err := h.userService.UpdateUserProfile(c.Context(), userID, payload.Name, file)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -8,55 +11,95 @@ import (
 	"github.com/google/uuid"
 )
 
-// NewAuthMiddleware creates a new middleware for JWT authentication.
-func NewAuthMiddleware(secretKey string) fiber.Handler {
+// ActiveUserFunc reports whether the user a valid token was issued to may still use it.
+type ActiveUserFunc func(ctx context.Context, userID uuid.UUID) (bool, error)
+
+// NewAuthMiddleware creates a new middleware for JWT authentication. Tokens stay valid until
+// they expire, so active is asked on every request whether their user, for example one
+// suspended since logging in, may still use them.
+func NewAuthMiddleware(secretKey string, active ActiveUserFunc) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get the Authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing or malformed JWT"})
+		userID, err := authenticate(c, secretKey)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		// Check if the header is in the format "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing or malformed JWT"})
+		ok, err := active(c.UserContext(), userID)
+		if err != nil {
+			slog.Error("Failed to check user of token", "userID", userID, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not check account"})
+		}
+		if !ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Account suspended or deleted"})
 		}
-		tokenString := parts[1]
 
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the alg is what you expect:
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Unexpected signing method")
-			}
-			return []byte(secretKey), nil
-		})
+		// Store the user ID in the request context for the next handler to use
+		c.Locals("current_user_id", userID)
 
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid or expired JWT"})
-		}
+		// Continue to the next handler
+		return c.Next()
+	}
+}
 
-		// Get claims and extract user ID
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid JWT claims"})
+// NewOptionalAuthMiddleware creates a JWT middleware for public routes.
+// A valid token stores the user ID in the request context just like NewAuthMiddleware does.
+// Requests without one, including those with an expired or invalid token, are served as
+// anonymous, so a client holding a stale token can still read public content. So are the
+// users active turns away.
+func NewOptionalAuthMiddleware(secretKey string, active ActiveUserFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if userID, err := authenticate(c, secretKey); err == nil {
+			if ok, err := active(c.UserContext(), userID); err == nil && ok {
+				c.Locals("current_user_id", userID)
+			}
 		}
+		return c.Next()
+	}
+}
 
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID in token"})
-		}
+// authenticate validates the bearer token of the request and returns the user ID it carries.
+func authenticate(c *fiber.Ctx, secretKey string) (uuid.UUID, error) {
+	// Get the Authorization header
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return uuid.Nil, errors.New("Missing or malformed JWT")
+	}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID format"})
+	// Check if the header is in the format "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return uuid.Nil, errors.New("Missing or malformed JWT")
+	}
+	tokenString := parts[1]
+
+	// Parse and validate the token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Validate the alg is what you expect:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Unexpected signing method")
 		}
+		return []byte(secretKey), nil
+	})
 
-		// Store the user ID in the request context for the next handler to use
-		c.Locals("current_user_id", userID)
+	if err != nil || !token.Valid {
+		return uuid.Nil, errors.New("Invalid or expired JWT")
+	}
 
-		// Continue to the next handler
-		return c.Next()
+	// Get claims and extract user ID
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, errors.New("Invalid JWT claims")
+	}
+
+	userIDStr, ok := claims["user_id"].(string)
+	if !ok {
+		return uuid.Nil, errors.New("Invalid user ID in token")
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, errors.New("Invalid user ID format")
 	}
+
+	return userID, nil
 }
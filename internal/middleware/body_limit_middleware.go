@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NewBodyLimitMiddleware creates a middleware that turns away request bodies over limit bytes.
+// The server streams request bodies instead of reading them up front, so this reads them in full
+// for the handlers, up to the limit. Requests for which streamed returns true go to handlers that
+// read the stream themselves and enforce their own limits.
+//
+// The server does not skip what is left of a body a handler stopped reading, so the connection is
+// closed after those requests; otherwise the rest of the body would be read as the next request.
+func NewBodyLimitMiddleware(limit int, streamed func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if streamed(c) {
+			c.Context().SetConnectionClose()
+			return c.Next()
+		}
+
+		// Chunked bodies report a length of -1, and are only found to be too large while reading
+		if c.Request().Header.ContentLength() > limit {
+			c.Context().SetConnectionClose()
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Request body too large"})
+		}
+		if stream := c.Context().RequestBodyStream(); stream != nil {
+			body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+			if err != nil {
+				c.Context().SetConnectionClose()
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Could not read request body"})
+			}
+			if len(body) > limit {
+				c.Context().SetConnectionClose()
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Request body too large"})
+			}
+			c.Request().SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
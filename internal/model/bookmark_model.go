@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BookmarkCollection is a named reading list a user can file bookmarks under.
+type BookmarkCollection struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (c *BookmarkCollection) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// Save creates or updates a collection record.
+func (c *BookmarkCollection) Save(db *gorm.DB) error {
+	return db.Save(c).Error
+}
+
+// FindAllByUser retrieves a user's collections in alphabetical order.
+func (c *BookmarkCollection) FindAllByUser(db *gorm.DB, userID uuid.UUID) ([]BookmarkCollection, error) {
+	var collections []BookmarkCollection
+	err := db.Where("user_id = ?", userID).Order("name").Find(&collections).Error
+	return collections, err
+}
+
+// FindByID retrieves one of a user's collections.
+func (c *BookmarkCollection) FindByID(db *gorm.DB, userID, id uuid.UUID) (*BookmarkCollection, error) {
+	var collection BookmarkCollection
+	err := db.Where("user_id = ? AND id = ?", userID, id).First(&collection).Error
+	return &collection, err
+}
+
+// FindByName retrieves one of a user's collections by its name.
+func (c *BookmarkCollection) FindByName(db *gorm.DB, userID uuid.UUID, name string) (*BookmarkCollection, error) {
+	var collection BookmarkCollection
+	err := db.Where("user_id = ? AND name = ?", userID, name).First(&collection).Error
+	return &collection, err
+}
+
+// Delete removes the collection. Its bookmarks are kept, without a collection.
+func (c *BookmarkCollection) Delete(db *gorm.DB) error {
+	return db.Delete(c).Error
+}
+
+// Bookmark is a post a user saved for later, optionally filed under a collection.
+type Bookmark struct {
+	UserID       uuid.UUID  `gorm:"type:char(36);primaryKey" json:"user_id"`
+	PostID       uuid.UUID  `gorm:"type:char(36);primaryKey" json:"post_id"`
+	CollectionID *uuid.UUID `gorm:"type:char(36)" json:"collection_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	Post Post `gorm:"foreignKey:PostID" json:"post"`
+}
+
+// BookmarkQuery describes which page of bookmarks FindPage returns.
+// Pages are ordered newest first and continue after the (AfterTime, AfterPostID) position.
+type BookmarkQuery struct {
+	UserID       uuid.UUID
+	CollectionID *uuid.UUID // nil lists bookmarks of every collection
+	AfterTime    time.Time  // zero starts from the newest bookmark
+	AfterPostID  uuid.UUID
+	Limit        int
+}
+
+// Save creates the bookmark. Bookmarking a post again only moves it to the given collection.
+func (b *Bookmark) Save(db *gorm.DB) error {
+	return db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(b).Error
+}
+
+// FindPage retrieves a page of a user's bookmarks with their posts. Bookmarks of posts
+// the user can no longer read, or that were deleted, are left out.
+func (b *Bookmark) FindPage(db *gorm.DB, query BookmarkQuery) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+
+	find := db.Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Scopes(ReadableBy(query.UserID)).
+		Where("bookmarks.user_id = ?", query.UserID)
+	if query.CollectionID != nil {
+		find = find.Where("bookmarks.collection_id = ?", *query.CollectionID)
+	}
+	if !query.AfterTime.IsZero() {
+		find = find.Where("(bookmarks.created_at < ? OR (bookmarks.created_at = ? AND bookmarks.post_id < ?))",
+			query.AfterTime, query.AfterTime, query.AfterPostID)
+	}
+
+	err := find.Preload("Post.User").Preload("Post.Attachments", inDisplayOrder).
+		Order("bookmarks.created_at desc, bookmarks.post_id desc").
+		Limit(query.Limit).Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+// FindBookmarkedPostIDs reports which of the given posts the user has bookmarked.
+func (b *Bookmark) FindBookmarkedPostIDs(db *gorm.DB, userID uuid.UUID, postIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	bookmarked := make(map[uuid.UUID]bool)
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []uuid.UUID
+	err := db.Model(&Bookmark{}).Where("user_id = ? AND post_id IN ?", userID, postIDs).Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
+
+// RemoveMany deletes the user's bookmarks of the given posts and returns how many were removed.
+func (b *Bookmark) RemoveMany(db *gorm.DB, userID uuid.UUID, postIDs []uuid.UUID) (int64, error) {
+	result := db.Where("user_id = ? AND post_id IN ?", userID, postIDs).Delete(&Bookmark{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// FindReferencedObjects returns the names of every object in storage that a record refers to.
+// Anything stored that is not in the list is deleted by the orphan collector once it is old
+// enough, so every table that refers to stored objects must be added here.
+func FindReferencedObjects(db *gorm.DB) (map[string]bool, error) {
+	refs := make(map[string]bool)
+
+	var users []User
+	err := db.Select("avatar_url", "avatar_variants").Where("avatar_url <> ''").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		addReferences(refs, user.AvatarObjects())
+	}
+
+	// Stored files are deleted by their own collector once nobody uses them
+	var files []StoredFile
+	if err := db.Select("object_name", "variants").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		addReferences(refs, file.Objects())
+	}
+
+	columns := []struct {
+		model  interface{}
+		column string
+	}{
+		{&PostAttachment{}, "file_name"},
+		{&ResumableUpload{}, "object_name"},
+		{&UploadTicket{}, "object_name"},
+		{&UploadJob{}, "object_name"},
+	}
+	for _, c := range columns {
+		var names []string
+		if err := db.Model(c.model).Distinct().Pluck(c.column, &names).Error; err != nil {
+			return nil, err
+		}
+		addReferences(refs, names)
+	}
+	return refs, nil
+}
+
+// FindTicketObjects returns the names of the objects open upload tickets are waiting for.
+func FindTicketObjects(db *gorm.DB) (map[string]bool, error) {
+	tickets := make(map[string]bool)
+	var names []string
+	if err := db.Model(&UploadTicket{}).Pluck("object_name", &names).Error; err != nil {
+		return nil, err
+	}
+	addReferences(tickets, names)
+	return tickets, nil
+}
+
+// FindPendingLocalObjects returns the names of the files kept on the server until they are
+// uploaded: those of open upload jobs and of resumable uploads still receiving chunks. Any
+// other file left in a temporary upload directory belongs to an upload that gave up.
+func FindPendingLocalObjects(db *gorm.DB) (map[string]bool, error) {
+	pending := make(map[string]bool)
+
+	var names []string
+	err := db.Model(&UploadJob{}).Where("status IN ?", []string{UploadJobPending, UploadJobRunning}).
+		Distinct().Pluck("object_name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	addReferences(pending, names)
+
+	names = nil
+	err = db.Model(&ResumableUpload{}).Where("status = ?", ResumableUploadUploading).
+		Pluck("object_name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+	addReferences(pending, names)
+	return pending, nil
+}
+
+// addReferences adds object names to a set of references.
+func addReferences(refs map[string]bool, names []string) {
+	for _, name := range names {
+		refs[name] = true
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Follow records that one user follows another.
+type Follow struct {
+	FollowerID uuid.UUID `gorm:"type:char(36);primaryKey" json:"follower_id"`
+	FolloweeID uuid.UUID `gorm:"type:char(36);primaryKey" json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Add creates the follow relation. Following someone twice is a no-op.
+func (f *Follow) Add(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(f).Error
+}
+
+// Remove deletes the follow relation if it exists.
+func (f *Follow) Remove(db *gorm.DB) error {
+	return db.Where("follower_id = ? AND followee_id = ?", f.FollowerID, f.FolloweeID).Delete(&Follow{}).Error
+}
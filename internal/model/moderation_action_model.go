@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actions a moderator can take on a reported post.
+const (
+	ModerationDismiss       = "dismiss"        // reports were unfounded; the post is shown again
+	ModerationHide          = "hide"           // the post stays hidden from everyone but its author
+	ModerationDelete        = "delete"         // the post is hidden and moved to the trash
+	ModerationSuspendAuthor = "suspend_author" // the post is hidden and its author can no longer log in
+)
+
+// ModerationAction records a decision taken on a post. ModeratorID is nil for automatic actions.
+type ModerationAction struct {
+	ID          uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	PostID      uuid.UUID  `gorm:"type:char(36);not null" json:"post_id"`
+	ModeratorID *uuid.UUID `gorm:"type:char(36)" json:"moderator_id"`
+	Action      string     `gorm:"size:32;not null" json:"action"`
+	Reason      string     `gorm:"size:500;not null" json:"reason"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (a *ModerationAction) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
+
+// Create stores the action.
+func (a *ModerationAction) Create(db *gorm.DB) error {
+	return db.Create(a).Error
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notification types.
+const (
+	NotificationMention  = "mention"  // the actor mentioned the user in a post
+	NotificationFollow   = "follow"   // the actor started following the user
+	NotificationReaction = "reaction" // the actor reacted to the user's post
+	NotificationInvite   = "invite"   // the actor invited the user to collaborate on a post
+)
+
+// NotificationTypes lists every notification type, in the order preferences are shown.
+var NotificationTypes = []string{NotificationMention, NotificationFollow, NotificationReaction, NotificationInvite}
+
+// Notification target types.
+const (
+	NotificationTargetPost = "post"
+	NotificationTargetUser = "user"
+)
+
+// Notification is an in-app alert for a user about something another user did.
+// DedupeKey, when set, guarantees the same event is never notified twice.
+type Notification struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:char(36);not null" json:"user_id"`
+	ActorID    *uuid.UUID `gorm:"type:char(36)" json:"actor_id"`
+	Type       string     `gorm:"size:32;not null" json:"type"`
+	TargetType string     `gorm:"size:32;not null" json:"target_type"`
+	TargetID   uuid.UUID  `gorm:"type:char(36);not null" json:"target_id"`
+	DedupeKey  *string    `gorm:"size:191" json:"-"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	Actor *User `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	n.ID = uuid.New()
+	return
+}
+
+// Create stores the notification. It returns false when a notification
+// with the same DedupeKey already exists, in which case nothing is stored.
+func (n *Notification) Create(db *gorm.DB) (bool, error) {
+	result := db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(n)
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindAllByUser retrieves a page of the user's notifications, newest first, with their actors.
+func (n *Notification) FindAllByUser(db *gorm.DB, userID uuid.UUID, unreadOnly bool, page, limit int) ([]Notification, int64, error) {
+	var notifications []Notification
+	var total int64
+
+	filter := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Where("user_id = ?", userID)
+		if unreadOnly {
+			tx = tx.Where("read_at IS NULL")
+		}
+		return tx
+	}
+
+	if err := db.Model(&Notification{}).Scopes(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := db.Scopes(filter).Preload("Actor").Order("created_at desc, id desc").
+		Limit(limit).Offset(offset).Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// FindByID retrieves one of the user's notifications with its actor.
+func (n *Notification) FindByID(db *gorm.DB, userID, id uuid.UUID) (*Notification, error) {
+	var notification Notification
+	err := db.Preload("Actor").Where("user_id = ? AND id = ?", userID, id).First(&notification).Error
+	return &notification, err
+}
+
+// CountUnread counts the user's unread notifications.
+func (n *Notification) CountUnread(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks the notification as read. Reading it again keeps the first read time.
+func (n *Notification) MarkRead(db *gorm.DB) error {
+	if n.ReadAt != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := db.Model(&Notification{}).Where("id = ?", n.ID).Update("read_at", now).Error; err != nil {
+		return err
+	}
+	n.ReadAt = &now
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and returns how many changed.
+func (n *Notification) MarkAllRead(db *gorm.DB, userID uuid.UUID) (int64, error) {
+	result := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// NotificationPreference records whether a user wants notifications of one type.
+// Types without a stored preference are enabled.
+type NotificationPreference struct {
+	UserID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"-"`
+	Type      string    `gorm:"size:32;primaryKey" json:"type"`
+	Enabled   bool      `gorm:"not null" json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Save stores the preference, replacing any earlier choice for the same type.
+func (p *NotificationPreference) Save(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(p).Error
+}
+
+// FindAllByUser retrieves the user's preferences as a map from type to enabled.
+// Every known type is present; types the user never changed are enabled.
+func (p *NotificationPreference) FindAllByUser(db *gorm.DB, userID uuid.UUID) (map[string]bool, error) {
+	var stored []NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]bool, len(NotificationTypes))
+	for _, notificationType := range NotificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+// IsEnabled reports whether the user wants notifications of the given type.
+func (p *NotificationPreference) IsEnabled(db *gorm.DB, userID uuid.UUID, notificationType string) (bool, error) {
+	var preference NotificationPreference
+	err := db.Where("user_id = ? AND type = ?", userID, notificationType).Limit(1).Find(&preference).Error
+	if err != nil {
+		return false, err
+	}
+	return preference.Type == "" || preference.Enabled, nil
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostAttachment is a file uploaded to a post. Like User.ImageStatus, Status tracks
+// the background upload: "uploading", then "local", then "cloud", or "failed" if it gave up.
+// Files uploaded through a ticket start out "local" while they wait in storage to be scanned.
+// Files the malware scanner flags end up "quarantined" and are never published.
+type PostAttachment struct {
+	ID           uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	PostID       uuid.UUID `gorm:"type:char(36);not null" json:"post_id"`
+	FileName     string    `gorm:"size:255;not null" json:"file_name"`
+	OriginalName string    `gorm:"size:255;not null" json:"original_name"`
+	ContentType  string    `gorm:"size:100;not null" json:"content_type"`
+	Size         int64     `gorm:"not null;default:0" json:"size"`
+	AltText      string    `gorm:"size:500;not null;default:''" json:"alt_text"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	Status       string    `gorm:"size:20;not null;default:'uploading'" json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Upload states of an attachment.
+const (
+	AttachmentStatusUploading   = "uploading"
+	AttachmentStatusLocal       = "local"
+	AttachmentStatusCloud       = "cloud"
+	AttachmentStatusFailed      = "failed"
+	AttachmentStatusQuarantined = "quarantined"
+)
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (a *PostAttachment) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
+
+// Save creates or updates an attachment record.
+func (a *PostAttachment) Save(db *gorm.DB) error {
+	return db.Save(a).Error
+}
+
+// UpdateStatus records the progress of the background upload.
+func (a *PostAttachment) UpdateStatus(db *gorm.DB, status string) error {
+	return db.Model(&PostAttachment{}).Where("id = ?", a.ID).Update("status", status).Error
+}
+
+// inDisplayOrder sorts attachments the way they are shown on the post.
+func inDisplayOrder(db *gorm.DB) *gorm.DB {
+	return db.Order("position, created_at")
+}
+
+// FindAllByPostID retrieves the attachments of a post in display order.
+func (a *PostAttachment) FindAllByPostID(db *gorm.DB, postID uuid.UUID) ([]PostAttachment, error) {
+	var attachments []PostAttachment
+	err := db.Scopes(inDisplayOrder).Where("post_id = ?", postID).Find(&attachments).Error
+	return attachments, err
+}
+
+// FindAllByPostIDs retrieves the attachments of several posts at once.
+func (a *PostAttachment) FindAllByPostIDs(db *gorm.DB, postIDs []uuid.UUID) ([]PostAttachment, error) {
+	var attachments []PostAttachment
+	if len(postIDs) == 0 {
+		return attachments, nil
+	}
+	err := db.Where("post_id IN ?", postIDs).Find(&attachments).Error
+	return attachments, err
+}
+
+// FindByID retrieves a single attachment of a post.
+func (a *PostAttachment) FindByID(db *gorm.DB, postID, id uuid.UUID) (*PostAttachment, error) {
+	var attachment PostAttachment
+	err := db.Where("post_id = ? AND id = ?", postID, id).First(&attachment).Error
+	return &attachment, err
+}
+
+// NextPosition returns the position after the last attachment of a post.
+func (a *PostAttachment) NextPosition(db *gorm.DB, postID uuid.UUID) (int, error) {
+	var next int
+	err := db.Model(&PostAttachment{}).Where("post_id = ?", postID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&next).Error
+	return next, err
+}
+
+// UpdateAltText changes the text shown in place of the attachment.
+func (a *PostAttachment) UpdateAltText(db *gorm.DB, altText string) error {
+	if err := db.Model(a).Update("alt_text", altText).Error; err != nil {
+		return err
+	}
+	a.AltText = altText
+	return nil
+}
+
+// UpdatePosition moves the attachment to a new place in the display order.
+func (a *PostAttachment) UpdatePosition(db *gorm.DB, position int) error {
+	if err := db.Model(a).Update("position", position).Error; err != nil {
+		return err
+	}
+	a.Position = position
+	return nil
+}
+
+// Delete removes the attachment record.
+func (a *PostAttachment) Delete(db *gorm.DB) error {
+	return db.Delete(a).Error
+}
+
+// FindStalled retrieves attachments left mid-upload without a job.
+func (a *PostAttachment) FindStalled(db *gorm.DB) ([]PostAttachment, error) {
+	var attachments []PostAttachment
+	err := db.Scopes(withoutUploadJob(UploadTargetAttachment, "post_attachments")).
+		Where("status IN ?", []string{AttachmentStatusUploading, AttachmentStatusLocal}).
+		Find(&attachments).Error
+	return attachments, err
+}
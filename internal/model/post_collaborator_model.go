@@ -0,0 +1,108 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Collaborator roles on a post. The post's author is always an owner without a collaborator record.
+const (
+	CollaboratorOwner  = "owner"  // everything the author can do
+	CollaboratorEditor = "editor" // edit the content, attachments and revisions
+	CollaboratorViewer = "viewer" // read the post, even while it is private
+)
+
+// Collaboration states. Invitations give no access until they are accepted.
+const (
+	CollaborationPending  = "pending"
+	CollaborationAccepted = "accepted"
+)
+
+// PostCollaborator gives a user other than the author a role on a post.
+type PostCollaborator struct {
+	PostID     uuid.UUID  `gorm:"type:char(36);primaryKey" json:"post_id"`
+	UserID     uuid.UUID  `gorm:"type:char(36);primaryKey" json:"user_id"`
+	Role       string     `gorm:"size:20;not null" json:"role"`
+	Status     string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	InvitedBy  *uuid.UUID `gorm:"type:char(36)" json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Post *Post `gorm:"foreignKey:PostID" json:"post,omitempty"`
+}
+
+// Create stores a new invitation.
+func (c *PostCollaborator) Create(db *gorm.DB) error {
+	return db.Omit(clause.Associations).Create(c).Error
+}
+
+// FindByUser retrieves the collaborator record of a user on a post, whatever its status.
+func (c *PostCollaborator) FindByUser(db *gorm.DB, postID, userID uuid.UUID) (*PostCollaborator, error) {
+	var collaborator PostCollaborator
+	err := db.Preload("User").Where("post_id = ? AND user_id = ?", postID, userID).First(&collaborator).Error
+	return &collaborator, err
+}
+
+// FindRole returns the role of an accepted collaborator, or an empty string
+// when the user has no accepted role on the post.
+func (c *PostCollaborator) FindRole(db *gorm.DB, postID, userID uuid.UUID) (string, error) {
+	var roles []string
+	err := db.Model(&PostCollaborator{}).
+		Where("post_id = ? AND user_id = ? AND status = ?", postID, userID, CollaborationAccepted).
+		Limit(1).Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
+}
+
+// FindAllByPostID retrieves every collaborator of a post, including pending invitations.
+func (c *PostCollaborator) FindAllByPostID(db *gorm.DB, postID uuid.UUID) ([]PostCollaborator, error) {
+	var collaborators []PostCollaborator
+	err := db.Preload("User").Where("post_id = ?", postID).Order("created_at").Find(&collaborators).Error
+	return collaborators, err
+}
+
+// FindPendingByUser retrieves the invitations a user has not answered yet, newest first.
+// Invitations to posts in the trash are left out.
+func (c *PostCollaborator) FindPendingByUser(db *gorm.DB, userID uuid.UUID) ([]PostCollaborator, error) {
+	var collaborators []PostCollaborator
+	err := db.Joins("JOIN posts ON posts.id = post_collaborators.post_id AND posts.deleted_at IS NULL").
+		Preload("Post.User").
+		Where("post_collaborators.user_id = ? AND post_collaborators.status = ?", userID, CollaborationPending).
+		Order("post_collaborators.created_at desc").
+		Find(&collaborators).Error
+	return collaborators, err
+}
+
+// UpdateRole changes the collaborator's role, keeping the state of the invitation.
+func (c *PostCollaborator) UpdateRole(db *gorm.DB, role string) error {
+	err := db.Model(&PostCollaborator{}).Where("post_id = ? AND user_id = ?", c.PostID, c.UserID).Update("role", role).Error
+	if err != nil {
+		return err
+	}
+	c.Role = role
+	return nil
+}
+
+// Accept turns the invitation into an active collaboration.
+func (c *PostCollaborator) Accept(db *gorm.DB) error {
+	now := time.Now()
+	err := db.Model(&PostCollaborator{}).Where("post_id = ? AND user_id = ?", c.PostID, c.UserID).
+		Updates(map[string]interface{}{"status": CollaborationAccepted, "accepted_at": now}).Error
+	if err != nil {
+		return err
+	}
+	c.Status = CollaborationAccepted
+	c.AcceptedAt = &now
+	return nil
+}
+
+// Remove deletes the collaborator record, ending the collaboration or withdrawing the invitation.
+func (c *PostCollaborator) Remove(db *gorm.DB) error {
+	return db.Where("post_id = ? AND user_id = ?", c.PostID, c.UserID).Delete(&PostCollaborator{}).Error
+}
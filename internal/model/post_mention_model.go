@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostMention links a post to a user mentioned in its body.
+type PostMention struct {
+	PostID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	UserID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReplaceForPost makes the given users the post's mentions. Links that already exist keep their creation time.
+func (m *PostMention) ReplaceForPost(tx *gorm.DB, postID uuid.UUID, userIDs []uuid.UUID) error {
+	stale := tx.Where("post_id = ?", postID)
+	if len(userIDs) > 0 {
+		stale = stale.Where("user_id NOT IN ?", userIDs)
+	}
+	if err := stale.Delete(&PostMention{}).Error; err != nil {
+		return err
+	}
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+	mentions := make([]PostMention, len(userIDs))
+	for i, userID := range userIDs {
+		mentions[i] = PostMention{PostID: postID, UserID: userID}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&mentions).Error
+}
+
+// FindUserIDsByPostID retrieves the IDs of the users mentioned in a post.
+func (m *PostMention) FindUserIDsByPostID(db *gorm.DB, postID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.Model(&PostMention{}).Where("post_id = ?", postID).Pluck("user_id", &ids).Error
+	return ids, err
+}
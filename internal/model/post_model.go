@@ -3,53 +3,205 @@ package model
 import (
 	"context"
 	"time"
+	"venturo-core/pkg/markdown"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Post defines the post model.
 type Post struct {
-	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
-	Title     string    `gorm:"size:255;not null" json:"title"`
-	Body      string    `gorm:"type:text" json:"body"`
-	UserID    uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	Title      string    `gorm:"size:255;not null" json:"title"`
+	Slug       string    `gorm:"size:255" json:"slug"`
+	Body       string    `gorm:"type:text" json:"body"`
+	BodyHTML   string    `gorm:"type:mediumtext" json:"-"`
+	Visibility string    `gorm:"size:20;not null;default:'public'" json:"visibility"`
+	UserID     uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Derived from Body whenever the post is saved
+	Excerpt     string `gorm:"size:300;not null;default:''" json:"excerpt"`
+	ReadingTime int    `gorm:"not null;default:0" json:"reading_time"`
+
+	// ReactionsCount is maintained with atomic SQL increments, so GORM only ever reads it
+	ReactionsCount int64 `gorm:"->" json:"reactions_count"`
+
+	// HiddenAt is set by moderators, or automatically once a post gathers enough reports.
+	// Hidden posts stay visible to their author only. It is changed with explicit updates, never through Save
+	HiddenAt *time.Time `gorm:"->" json:"hidden_at,omitempty"`
+
+	// Posts are soft deleted; GORM excludes trashed rows from normal queries automatically
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 
 	// Define the relationship to the User model
 	User User `gorm:"foreignKey:UserID" json:"author,omitempty"`
+
+	// Attachments are managed through their own endpoints and are only ever loaded with the post
+	Attachments []PostAttachment `gorm:"foreignKey:PostID" json:"attachments"`
+
+	// Tags are stored in post_tags and loaded with listings and single-post reads
+	Tags []string `gorm:"-" json:"tags,omitempty"`
+
+	// Computed per request; not stored on the posts table
+	BodyFormat  string           `gorm:"-" json:"body_format,omitempty"`
+	Reactions   map[string]int64 `gorm:"-" json:"reactions,omitempty"`
+	MyReactions []string         `gorm:"-" json:"my_reactions,omitempty"`
+
+	// IsBookmarked is only set for authenticated callers
+	IsBookmarked *bool `gorm:"-" json:"is_bookmarked,omitempty"`
+
+	// Series is only set on single-post reads of posts that belong to a series
+	Series *SeriesNavigation `gorm:"-" json:"series,omitempty"`
 }
 
+// Visibility levels of a post.
+const (
+	VisibilityPublic    = "public"    // listed and readable by anyone
+	VisibilityUnlisted  = "unlisted"  // readable by anyone with the link, never listed
+	VisibilityPrivate   = "private"   // only the author
+	VisibilityFollowers = "followers" // listed and readable for the author's followers
+)
+
+// Body formats a post can be returned in. Body is always stored as CommonMark.
+const (
+	PostFormatMarkdown = "markdown"
+	PostFormatHTML     = "html"
+	PostFormatPlain    = "plain"
+)
+
+// excerptLength is the maximum number of characters in an auto-generated excerpt.
+const excerptLength = 200
+
+// Sort orders accepted by FindAll.
+const (
+	PostSortLatest  = "latest"
+	PostSortPopular = "popular"
+)
+
 // BeforeCreate is a GORM hook that runs before a new record is created.
 func (p *Post) BeforeCreate(tx *gorm.DB) (err error) {
 	p.ID = uuid.New()
 	return
 }
 
+// BeforeSave is a GORM hook that renders the markdown body before every create or update.
+func (p *Post) BeforeSave(tx *gorm.DB) (err error) {
+	if p.BodyHTML, err = markdown.ToHTML(p.Body); err != nil {
+		return err
+	}
+
+	plain := markdown.ToPlainText(p.BodyHTML)
+	p.Excerpt = markdown.Excerpt(plain, excerptLength)
+	p.ReadingTime = markdown.ReadingTime(plain)
+	return nil
+}
+
+// ApplyFormat replaces Body with the requested representation of the content.
+func (p *Post) ApplyFormat(format string) error {
+	// Posts saved before rendering existed have no cached HTML yet
+	if p.BodyHTML == "" && p.Body != "" {
+		rendered, err := markdown.ToHTML(p.Body)
+		if err != nil {
+			return err
+		}
+		p.BodyHTML = rendered
+	}
+
+	switch format {
+	case PostFormatHTML:
+		p.Body = p.BodyHTML
+	case PostFormatPlain:
+		p.Body = markdown.ToPlainText(p.BodyHTML)
+	}
+	p.BodyFormat = format
+	return nil
+}
+
 // Save creates or updates a post record.
 func (p *Post) Save(db *gorm.DB) error {
 	return db.WithContext(context.Background()).Save(p).Error
 }
 
-// FindAll retrieves all post records, preloading the author data.
-func (p *Post) FindAll(db *gorm.DB, page, limit int) ([]Post, int64, error) {
+// PostQuery describes which page of posts FindAll returns.
+type PostQuery struct {
+	Page     int
+	Limit    int
+	Sort     string    // PostSortLatest or PostSortPopular
+	AuthorID uuid.UUID // uuid.Nil lists posts of every author
+	Tag      string    // Normalized tag to filter by; empty lists posts whatever their tags
+	ViewerID uuid.UUID // uuid.Nil for anonymous callers
+}
+
+// ListableBy limits a query to posts that may appear in listings for the viewer:
+// public posts, followers-only posts of authors the viewer follows, and posts the viewer writes or collaborates on.
+func ListableBy(viewerID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return visibleTo(viewerID, VisibilityPublic)
+}
+
+// ReadableBy limits a query to posts the viewer may open directly. Unlike listings,
+// this includes unlisted posts, which anyone holding the link can read.
+func ReadableBy(viewerID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return visibleTo(viewerID, VisibilityPublic, VisibilityUnlisted)
+}
+
+// visibleTo builds the visibility condition shared by ListableBy and ReadableBy.
+// The author and accepted collaborators see the post whatever its visibility.
+// Posts hidden by moderation are left out for everyone else.
+func visibleTo(viewerID uuid.UUID, openTo ...string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if viewerID == uuid.Nil {
+			return tx.Where("posts.hidden_at IS NULL AND posts.visibility IN ?", openTo)
+		}
+		return tx.Where(
+			"(posts.user_id = ? OR EXISTS (SELECT 1 FROM post_collaborators WHERE post_collaborators.post_id = posts.id "+
+				"AND post_collaborators.user_id = ? AND post_collaborators.status = ?) "+
+				"OR (posts.hidden_at IS NULL AND (posts.visibility IN ? OR (posts.visibility = ? AND EXISTS "+
+				"(SELECT 1 FROM follows WHERE follows.follower_id = ? AND follows.followee_id = posts.user_id)))))",
+			viewerID, viewerID, CollaborationAccepted, openTo, VisibilityFollowers, viewerID,
+		)
+	}
+}
+
+// FindAll retrieves all post records matching the query, preloading the author data.
+// Posts are ordered newest first, or by total reactions when sort is PostSortPopular.
+func (p *Post) FindAll(db *gorm.DB, query PostQuery) ([]Post, int64, error) {
 	var posts []Post
 	var total int64
 
+	filter := func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Scopes(ListableBy(query.ViewerID))
+		if query.AuthorID != uuid.Nil {
+			tx = tx.Where("posts.user_id = ?", query.AuthorID)
+		}
+		if query.Tag != "" {
+			tx = tx.Scopes(taggedWith(query.Tag))
+		}
+		return tx
+	}
+
 	// 1. Get the total count of posts
-	if err := db.Model(&Post{}).Count(&total).Error; err != nil {
+	if err := db.Model(&Post{}).Scopes(filter).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 2. Calculate the offset for pagination
-	offset := (page - 1) * limit
+	offset := (query.Page - 1) * query.Limit
 
 	// 3. Get the paginated data
-	err := db.Limit(limit).Offset(offset).Preload("User").Order("created_at desc").Find(&posts).Error
+	find := db.Scopes(filter).Limit(query.Limit).Offset(offset).Preload("User").Preload("Attachments", inDisplayOrder)
+	if query.Sort == PostSortPopular {
+		find = find.Order("reactions_count desc")
+	}
+	err := find.Order("created_at desc").Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := loadTags(db, posts); err != nil {
+		return nil, 0, err
+	}
 
 	return posts, total, nil
 }
@@ -61,7 +213,135 @@ func (p *Post) FindByID(db *gorm.DB, id uuid.UUID) (*Post, error) {
 	return &post, err
 }
 
-// Delete removes a post record from the database.
+// IsReadableBy reports whether the viewer may open the post.
+func (p *Post) IsReadableBy(db *gorm.DB, viewerID uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&Post{}).Scopes(ReadableBy(viewerID)).Where("posts.id = ?", p.ID).Count(&count).Error
+	return count > 0, err
+}
+
+// FindAllByIDs retrieves the given posts, preloading their authors.
+func (p *Post) FindAllByIDs(db *gorm.DB, ids []uuid.UUID) ([]Post, error) {
+	var posts []Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	err := db.Preload("User").Where("id IN ?", ids).Find(&posts).Error
+	return posts, err
+}
+
+// FindByIDForUpdate retrieves a post and locks its row until the surrounding transaction ends.
+func (p *Post) FindByIDForUpdate(tx *gorm.DB, id uuid.UUID) (*Post, error) {
+	var post Post
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&post).Error
+	return &post, err
+}
+
+// FindTrashedByUser retrieves a user's soft-deleted posts, most recently deleted first.
+func (p *Post) FindTrashedByUser(db *gorm.DB, userID uuid.UUID, page, limit int) ([]Post, int64, error) {
+	var posts []Post
+	var total int64
+
+	trashed := db.Unscoped().Model(&Post{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	if err := trashed.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Limit(limit).Offset(offset).Preload("User").Order("deleted_at desc").Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, total, nil
+}
+
+// FindTrashedByID retrieves a single soft-deleted post by its ID.
+func (p *Post) FindTrashedByID(db *gorm.DB, id uuid.UUID) (*Post, error) {
+	var post Post
+	err := db.Unscoped().Preload("User").Where("id = ? AND deleted_at IS NOT NULL", id).First(&post).Error
+	return &post, err
+}
+
+// FindWithoutSlugForUpdate retrieves posts created before posts had slugs, trashed ones included,
+// oldest first, and locks them until the transaction ends.
+func (p *Post) FindWithoutSlugForUpdate(tx *gorm.DB, limit int) ([]Post, error) {
+	var posts []Post
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("slug IS NULL OR slug = ''").Order("created_at").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// UpdateSlug stores a new current slug without touching updated_at.
+func (p *Post) UpdateSlug(db *gorm.DB, slug string) error {
+	if err := db.Model(p).UpdateColumn("slug", slug).Error; err != nil {
+		return err
+	}
+	p.Slug = slug
+	return nil
+}
+
+// UpdateVisibility changes who can see the post.
+func (p *Post) UpdateVisibility(db *gorm.DB, visibility string) error {
+	if err := db.Model(p).Update("visibility", visibility).Error; err != nil {
+		return err
+	}
+	p.Visibility = visibility
+	return nil
+}
+
+// Hide takes the post out of everyone's view but its author's. Hiding a hidden post keeps its original time.
+func (p *Post) Hide(db *gorm.DB) error {
+	if p.HiddenAt != nil {
+		return nil
+	}
+	now := time.Now()
+	if err := db.Unscoped().Model(&Post{}).Where("id = ?", p.ID).UpdateColumn("hidden_at", now).Error; err != nil {
+		return err
+	}
+	p.HiddenAt = &now
+	return nil
+}
+
+// Unhide makes a hidden post visible again.
+func (p *Post) Unhide(db *gorm.DB) error {
+	if err := db.Unscoped().Model(&Post{}).Where("id = ?", p.ID).UpdateColumn("hidden_at", nil).Error; err != nil {
+		return err
+	}
+	p.HiddenAt = nil
+	return nil
+}
+
+// Delete moves a post to the trash by setting its deleted_at timestamp.
 func (p *Post) Delete(db *gorm.DB) error {
 	return db.Delete(p).Error
 }
+
+// Restore takes a post out of the trash.
+func (p *Post) Restore(db *gorm.DB) error {
+	if err := db.Unscoped().Model(p).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	p.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// FindTrashedBeforeForUpdate retrieves the IDs of posts trashed before the cutoff
+// and locks them, so they cannot be restored while they are being purged.
+func (p *Post) FindTrashedBeforeForUpdate(tx *gorm.DB, cutoff time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Unscoped().Model(&Post{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// PurgeByIDs permanently removes the given posts. Their attachments, revisions
+// and reactions are removed with them by the foreign keys.
+func (p *Post) PurgeByIDs(tx *gorm.DB, ids []uuid.UUID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := tx.Unscoped().Where("id IN ?", ids).Delete(&Post{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostReaction records a single user's reaction of one type to a post.
+type PostReaction struct {
+	PostID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	UserID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"user_id"`
+	Type      string    `gorm:"size:32;primaryKey" json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostReactionCount holds the aggregated number of reactions of one type on a post.
+type PostReactionCount struct {
+	PostID uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	Type   string    `gorm:"size:32;primaryKey" json:"type"`
+	Count  int64     `gorm:"not null;default:0" json:"count"`
+}
+
+// Add inserts the reaction, reporting false when the user had already reacted with this type.
+func (r *PostReaction) Add(tx *gorm.DB) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(r)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Remove deletes the reaction, reporting false when there was nothing to delete.
+func (r *PostReaction) Remove(tx *gorm.DB) (bool, error) {
+	result := tx.Where("post_id = ? AND user_id = ? AND type = ?", r.PostID, r.UserID, r.Type).Delete(&PostReaction{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindTypesByUser returns the reaction types a user has left on a post.
+func (r *PostReaction) FindTypesByUser(db *gorm.DB, postID, userID uuid.UUID) ([]string, error) {
+	var types []string
+	err := db.Model(&PostReaction{}).Where("post_id = ? AND user_id = ?", postID, userID).
+		Order("created_at").Pluck("type", &types).Error
+	return types, err
+}
+
+// AdjustCount atomically moves the counter of a reaction type, and the post's total, by delta.
+// The update happens in SQL so that concurrent reactions never overwrite each other.
+func (c *PostReactionCount) AdjustCount(tx *gorm.DB, postID uuid.UUID, reactionType string, delta int) error {
+	counter := PostReactionCount{PostID: postID, Type: reactionType, Count: int64(delta)}
+	err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("count + ?", delta)}),
+	}).Create(&counter).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&Post{}).Where("id = ?", postID).
+		UpdateColumn("reactions_count", gorm.Expr("reactions_count + ?", delta)).Error
+}
+
+// FindByPostID returns the non-zero reaction counts of a post keyed by type.
+func (c *PostReactionCount) FindByPostID(db *gorm.DB, postID uuid.UUID) (map[string]int64, error) {
+	var counters []PostReactionCount
+	if err := db.Where("post_id = ? AND count > 0", postID).Find(&counters).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(counters))
+	for _, counter := range counters {
+		counts[counter.Type] = counter.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Report statuses. A report stays open until a moderator acts on its post.
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+)
+
+// Reason codes a post can be reported for.
+const (
+	ReportReasonSpam           = "spam"
+	ReportReasonHarassment     = "harassment"
+	ReportReasonHateSpeech     = "hate_speech"
+	ReportReasonViolence       = "violence"
+	ReportReasonSexualContent  = "sexual_content"
+	ReportReasonMisinformation = "misinformation"
+	ReportReasonOther          = "other"
+)
+
+// PostReport is a user's complaint about a post. Each user can report a post once.
+type PostReport struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	PostID     uuid.UUID  `gorm:"type:char(36);not null" json:"post_id"`
+	ReporterID uuid.UUID  `gorm:"type:char(36);not null" json:"reporter_id"`
+	Reason     string     `gorm:"size:32;not null" json:"reason"`
+	Note       string     `gorm:"size:500;not null;default:''" json:"note,omitempty"`
+	Status     string     `gorm:"size:20;not null;default:'open'" json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// ReportedPost is an entry of the moderation queue: a post with its open reports summed up.
+type ReportedPost struct {
+	PostID          uuid.UUID        `json:"post_id"`
+	ReportCount     int64            `json:"report_count"`
+	FirstReportedAt time.Time        `json:"first_reported_at"`
+	LastReportedAt  time.Time        `json:"last_reported_at"`
+	Reasons         map[string]int64 `gorm:"-" json:"reasons"`
+	Post            *Post            `gorm:"-" json:"post"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (r *PostReport) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// Create stores the report. It returns false when the user has already reported the post.
+func (r *PostReport) Create(db *gorm.DB) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(r)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CountOpenByPostID counts the reports of a post that are still waiting for review.
+func (r *PostReport) CountOpenByPostID(db *gorm.DB, postID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&PostReport{}).Where("post_id = ? AND status = ?", postID, ReportStatusOpen).Count(&count).Error
+	return count, err
+}
+
+// ResolveOpenByPostID closes every open report of a post.
+func (r *PostReport) ResolveOpenByPostID(db *gorm.DB, postID uuid.UUID) error {
+	return db.Model(&PostReport{}).Where("post_id = ? AND status = ?", postID, ReportStatusOpen).
+		Updates(map[string]interface{}{"status": ReportStatusResolved, "resolved_at": time.Now()}).Error
+}
+
+// FindQueue retrieves a page of posts with open reports, most reported first,
+// then longest waiting first.
+func (r *PostReport) FindQueue(db *gorm.DB, page, limit int) ([]ReportedPost, int64, error) {
+	var entries []ReportedPost
+	var total int64
+
+	open := db.Model(&PostReport{}).Where("status = ?", ReportStatusOpen)
+	if err := open.Distinct("post_id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := db.Model(&PostReport{}).
+		Select("post_id, COUNT(*) AS report_count, MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at").
+		Where("status = ?", ReportStatusOpen).
+		Group("post_id").
+		Order("report_count desc, first_reported_at asc").
+		Limit(limit).Offset(offset).
+		Scan(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// CountOpenReasons tallies the open reports of the given posts by reason.
+func (r *PostReport) CountOpenReasons(db *gorm.DB, postIDs []uuid.UUID) (map[uuid.UUID]map[string]int64, error) {
+	reasons := make(map[uuid.UUID]map[string]int64)
+	if len(postIDs) == 0 {
+		return reasons, nil
+	}
+
+	var rows []struct {
+		PostID uuid.UUID
+		Reason string
+		Count  int64
+	}
+	err := db.Model(&PostReport{}).
+		Select("post_id, reason, COUNT(*) AS count").
+		Where("status = ? AND post_id IN ?", ReportStatusOpen, postIDs).
+		Group("post_id, reason").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if reasons[row.PostID] == nil {
+			reasons[row.PostID] = make(map[string]int64)
+		}
+		reasons[row.PostID][row.Reason] = row.Count
+	}
+	return reasons, nil
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostRevision is an immutable snapshot of a post's content, written on every edit.
+type PostRevision struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	PostID    uuid.UUID `gorm:"type:char(36);not null" json:"post_id"`
+	Revision  int       `gorm:"not null" json:"revision"`
+	Title     string    `gorm:"size:255;not null" json:"title"`
+	Body      string    `gorm:"type:text" json:"body"`
+	EditorID  uuid.UUID `gorm:"type:char(36);not null" json:"editor_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Define the relationship to the User model
+	Editor User `gorm:"foreignKey:EditorID" json:"editor,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (r *PostRevision) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return
+}
+
+// Create inserts the revision. Revisions are never updated once written.
+func (r *PostRevision) Create(db *gorm.DB) error {
+	return db.Omit("Editor").Create(r).Error
+}
+
+// FindAllByPostID retrieves every revision of a post, newest first.
+func (r *PostRevision) FindAllByPostID(db *gorm.DB, postID uuid.UUID) ([]PostRevision, error) {
+	var revisions []PostRevision
+	err := db.Preload("Editor").Where("post_id = ?", postID).Order("revision desc").Find(&revisions).Error
+	return revisions, err
+}
+
+// FindByRevision retrieves a single revision of a post by its number.
+func (r *PostRevision) FindByRevision(db *gorm.DB, postID uuid.UUID, revision int) (*PostRevision, error) {
+	var rev PostRevision
+	err := db.Preload("Editor").Where("post_id = ? AND revision = ?", postID, revision).First(&rev).Error
+	return &rev, err
+}
+
+// LatestRevisionNumber returns the highest revision number of a post, or 0 when it has none.
+func (r *PostRevision) LatestRevisionNumber(db *gorm.DB, postID uuid.UUID) (int, error) {
+	var latest int
+	err := db.Model(&PostRevision{}).Where("post_id = ?", postID).Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error
+	return latest, err
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxSlugLength leaves room for a numeric suffix within the 255 character column.
+const maxSlugLength = 200
+
+// PostSlug records every slug a post has ever had. The current one lives on Post.Slug;
+// the others are kept so that old links keep redirecting, and are never handed to another post.
+// Base is the slug the title mapped to when the post claimed this one, before any suffix added
+// to resolve a collision; it is empty for slugs claimed before it was recorded.
+type PostSlug struct {
+	Slug      string    `gorm:"size:255;primaryKey" json:"slug"`
+	PostID    uuid.UUID `gorm:"type:char(36);not null" json:"post_id"`
+	Base      string    `gorm:"size:255;not null;default:''" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MakeSlug transliterates a title into its base URL slug.
+func MakeSlug(title string) string {
+	base := slug.Make(title)
+	if len(base) > maxSlugLength {
+		base = strings.TrimRight(base[:maxSlugLength], "-")
+	}
+	if base == "" {
+		base = "post"
+	}
+	return base
+}
+
+// Claim reserves the first free variant of base for the post and returns it.
+// A slug the post owned before is handed back to it, so reverting a title restores its old URL,
+// and is recorded as claimed for base from then on.
+func (s *PostSlug) Claim(tx *gorm.DB, postID uuid.UUID, base string) (string, error) {
+	for n := 1; ; n++ {
+		candidate := base
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, n)
+		}
+		// Fall back to a random suffix rather than probing forever on very common titles
+		if n > 20 {
+			candidate = fmt.Sprintf("%s-%s", base, uuid.New().String()[:8])
+		}
+
+		// The primary key makes the insert itself the uniqueness check, so concurrent claims cannot collide
+		row := PostSlug{Slug: candidate, PostID: postID, Base: base}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if result.Error != nil {
+			return "", result.Error
+		}
+		if result.RowsAffected == 1 {
+			return candidate, nil
+		}
+
+		owner, err := s.FindBySlug(tx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if owner.PostID == postID {
+			if owner.Base != base {
+				err := tx.Model(&PostSlug{}).Where("slug = ?", candidate).Update("base", base).Error
+				if err != nil {
+					return "", err
+				}
+			}
+			return candidate, nil
+		}
+	}
+}
+
+// FindBySlug retrieves the slug record, whether current or retired.
+func (s *PostSlug) FindBySlug(db *gorm.DB, value string) (*PostSlug, error) {
+	var postSlug PostSlug
+	err := db.Where("slug = ?", value).First(&postSlug).Error
+	return &postSlug, err
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+// maxTagLength matches the size of the tag column.
+const maxTagLength = 50
+
+// PostTag files a post under a tag. Tags are stored in their slug form, so "Go" and "go"
+// are the same tag and can be used in URLs as they are.
+type PostTag struct {
+	PostID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	Tag       string    `gorm:"size:50;primaryKey" json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeTag turns a tag as typed by a user into its stored slug form.
+func NormalizeTag(tag string) string {
+	normalized := slug.Make(tag)
+	if len(normalized) > maxTagLength {
+		normalized = strings.TrimRight(normalized[:maxTagLength], "-")
+	}
+	return normalized
+}
+
+// NormalizeTags normalizes a list of tags, dropping empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
+// ReplaceForPost sets the tags of a post, dropping any it had before. The tags must be normalized.
+func (t *PostTag) ReplaceForPost(tx *gorm.DB, postID uuid.UUID, tags []string) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&PostTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]PostTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = PostTag{PostID: postID, Tag: tag}
+	}
+	return tx.Create(&rows).Error
+}
+
+// FindByPostIDs retrieves the tags of the given posts, in alphabetical order.
+func (t *PostTag) FindByPostIDs(db *gorm.DB, postIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	tags := make(map[uuid.UUID][]string)
+	if len(postIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []PostTag
+	if err := db.Where("post_id IN ?", postIDs).Order("tag").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.PostID] = append(tags[row.PostID], row.Tag)
+	}
+	return tags, nil
+}
+
+// loadTags fills in the tags of each post.
+func loadTags(db *gorm.DB, posts []Post) error {
+	ids := make([]uuid.UUID, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	var postTag PostTag
+	tags, err := postTag.FindByPostIDs(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Tags = tags[posts[i].ID]
+	}
+	return nil
+}
+
+// taggedWith limits a query on posts to those filed under the tag.
+func taggedWith(tag string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = posts.id AND pt.tag = ?)", tag)
+	}
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DirectReferrer stands in for views that did not come from a link on another site.
+const DirectReferrer = "(direct)"
+
+// PostViewStat counts the views of a post on one day.
+type PostViewStat struct {
+	PostID uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	Day    time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Views  int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// PostViewVisitor records that a visitor viewed a post on one day. Visitors are
+// stored as hashes, so they can be counted without keeping who they are.
+type PostViewVisitor struct {
+	PostID  uuid.UUID `gorm:"type:char(36);primaryKey"`
+	Day     time.Time `gorm:"type:date;primaryKey"`
+	Visitor string    `gorm:"type:char(64);primaryKey"`
+}
+
+// PostViewReferrer counts the views of a post that came from one referring site on one day.
+type PostViewReferrer struct {
+	PostID   uuid.UUID `gorm:"type:char(36);primaryKey" json:"-"`
+	Day      time.Time `gorm:"type:date;primaryKey" json:"-"`
+	Referrer string    `gorm:"size:255;primaryKey" json:"referrer"`
+	Views    int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// DailyViews is the traffic of a post on one day.
+type DailyViews struct {
+	Day            time.Time `json:"day"`
+	Views          int64     `json:"views"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+}
+
+// viewBatchSize caps the rows written by a single insert.
+const viewBatchSize = 500
+
+// AddMany adds the given view counts to the stored daily totals.
+func (s *PostViewStat) AddMany(tx *gorm.DB, stats []PostViewStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("views + VALUES(views)")}),
+	}).CreateInBatches(&stats, viewBatchSize).Error
+}
+
+// FindDaily retrieves the views and unique visitors of a post for each day in [from, to]
+// that had any traffic, oldest first.
+func (s *PostViewStat) FindDaily(db *gorm.DB, postID uuid.UUID, from, to time.Time) ([]DailyViews, error) {
+	var days []DailyViews
+	err := db.Model(&PostViewStat{}).
+		Select("post_view_stats.day, post_view_stats.views, "+
+			"(SELECT COUNT(*) FROM post_view_visitors v WHERE v.post_id = post_view_stats.post_id AND v.day = post_view_stats.day) AS unique_visitors").
+		Where("post_view_stats.post_id = ? AND post_view_stats.day BETWEEN ? AND ?", postID, from, to).
+		Order("post_view_stats.day").
+		Scan(&days).Error
+	return days, err
+}
+
+// AddMany records the given visitors. Visitors already recorded for the day are skipped.
+func (v *PostViewVisitor) AddMany(tx *gorm.DB, visitors []PostViewVisitor) error {
+	if len(visitors) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&visitors, viewBatchSize).Error
+}
+
+// CountUnique counts the distinct visitors of a post over [from, to].
+func (v *PostViewVisitor) CountUnique(db *gorm.DB, postID uuid.UUID, from, to time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&PostViewVisitor{}).
+		Where("post_id = ? AND day BETWEEN ? AND ?", postID, from, to).
+		Distinct("visitor").Count(&count).Error
+	return count, err
+}
+
+// AddMany adds the given referrer counts to the stored daily totals.
+func (r *PostViewReferrer) AddMany(tx *gorm.DB, referrers []PostViewReferrer) error {
+	if len(referrers) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("views + VALUES(views)")}),
+	}).CreateInBatches(&referrers, viewBatchSize).Error
+}
+
+// FindTop retrieves the sites that sent a post the most views over [from, to].
+func (r *PostViewReferrer) FindTop(db *gorm.DB, postID uuid.UUID, from, to time.Time, limit int) ([]PostViewReferrer, error) {
+	var referrers []PostViewReferrer
+	err := db.Model(&PostViewReferrer{}).
+		Select("referrer, SUM(views) AS views").
+		Where("post_id = ? AND day BETWEEN ? AND ?", postID, from, to).
+		Group("referrer").Order("views desc, referrer").Limit(limit).
+		Scan(&referrers).Error
+	return referrers, err
+}
+
+// FindExistingIDs returns which of the given post IDs still exist, including posts in the trash.
+func (p *Post) FindExistingIDs(db *gorm.DB, ids []uuid.UUID) ([]uuid.UUID, error) {
+	var existing []uuid.UUID
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	err := db.Unscoped().Model(&Post{}).Where("id IN ?", ids).Pluck("id", &existing).Error
+	return existing, err
+}
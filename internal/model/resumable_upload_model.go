@@ -0,0 +1,103 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResumableUpload is a file sent in chunks through the tus protocol. Offset counts the bytes
+// received so far; once it reaches Length the file is handed to the upload queue. Status
+// follows PostAttachment.Status: "uploading" while chunks arrive, then "local", then "cloud",
+// or "failed" if the move to storage gave up, or "quarantined" if the file is infected.
+type ResumableUpload struct {
+	ID           uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID       uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
+	Length       int64     `gorm:"column:upload_length;not null" json:"length"`
+	Offset       int64     `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Metadata     string    `gorm:"size:1000;not null;default:''" json:"-"`
+	OriginalName string    `gorm:"size:255;not null;default:''" json:"original_name"`
+	ObjectName   string    `gorm:"size:255;not null" json:"object_name"`
+	ContentType  string    `gorm:"size:100;not null;default:''" json:"content_type"`
+	Status       string    `gorm:"size:20;not null;default:'uploading'" json:"status"`
+	URL          string    `gorm:"-" json:"url,omitempty"` // Set once the file is in storage
+	ExpiresAt    time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// States of a resumable upload.
+const (
+	ResumableUploadUploading   = "uploading"
+	ResumableUploadLocal       = "local"
+	ResumableUploadCloud       = "cloud"
+	ResumableUploadFailed      = "failed"
+	ResumableUploadQuarantined = "quarantined"
+)
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (u *ResumableUpload) BeforeCreate(tx *gorm.DB) (err error) {
+	u.ID = uuid.New()
+	return
+}
+
+// Create adds a new upload record.
+func (u *ResumableUpload) Create(db *gorm.DB) error {
+	return db.Create(u).Error
+}
+
+// FindByID retrieves an upload by its ID.
+func (u *ResumableUpload) FindByID(db *gorm.DB, id uuid.UUID) (*ResumableUpload, error) {
+	var upload ResumableUpload
+	err := db.Where("id = ?", id).First(&upload).Error
+	return &upload, err
+}
+
+// FindByIDForUpdate retrieves an upload and locks it until the transaction ends,
+// so chunks of the same upload are written one at a time.
+func (u *ResumableUpload) FindByIDForUpdate(tx *gorm.DB, id uuid.UUID) (*ResumableUpload, error) {
+	var upload ResumableUpload
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&upload).Error
+	return &upload, err
+}
+
+// UpdateProgress records the bytes received so far and pushes back the expiry.
+func (u *ResumableUpload) UpdateProgress(db *gorm.DB, offset int64, expiresAt time.Time) error {
+	err := db.Model(&ResumableUpload{}).Where("id = ?", u.ID).
+		Updates(map[string]interface{}{"upload_offset": offset, "expires_at": expiresAt}).Error
+	if err != nil {
+		return err
+	}
+	u.Offset = offset
+	u.ExpiresAt = expiresAt
+	return nil
+}
+
+// UpdateStatus records the progress of the upload.
+func (u *ResumableUpload) UpdateStatus(db *gorm.DB, status string) error {
+	return db.Model(&ResumableUpload{}).Where("id = ?", u.ID).Update("status", status).Error
+}
+
+// Delete removes the upload record.
+func (u *ResumableUpload) Delete(db *gorm.DB) error {
+	return db.Where("id = ?", u.ID).Delete(&ResumableUpload{}).Error
+}
+
+// FindExpired retrieves unfinished uploads that were not resumed before they expired.
+func (u *ResumableUpload) FindExpired(db *gorm.DB, now time.Time, limit int) ([]ResumableUpload, error) {
+	var uploads []ResumableUpload
+	err := db.Where("status = ? AND expires_at <= ?", ResumableUploadUploading, now).
+		Order("expires_at").Limit(limit).Find(&uploads).Error
+	return uploads, err
+}
+
+// FindStalled retrieves finished resumable uploads left without a job.
+func (u *ResumableUpload) FindStalled(db *gorm.DB) ([]ResumableUpload, error) {
+	var uploads []ResumableUpload
+	err := db.Scopes(withoutUploadJob(UploadTargetResumable, "resumable_uploads")).
+		Where("status = ?", ResumableUploadLocal).
+		Find(&uploads).Error
+	return uploads, err
+}
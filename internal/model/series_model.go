@@ -0,0 +1,146 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Series is an ordered collection of posts by one author, such as a multi-part tutorial.
+type Series struct {
+	ID          uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
+	Title       string    `gorm:"size:255;not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID" json:"author,omitempty"`
+
+	// Posts are loaded separately, filtered by what the viewer may read
+	Posts []Post `gorm:"-" json:"posts,omitempty"`
+}
+
+// SeriesPost places a post in a series. A post belongs to at most one series.
+type SeriesPost struct {
+	PostID    uuid.UUID `gorm:"type:char(36);primaryKey" json:"post_id"`
+	SeriesID  uuid.UUID `gorm:"type:char(36);not null" json:"series_id"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SeriesEntry is the part of a series post needed to link to it.
+type SeriesEntry struct {
+	PostID uuid.UUID `json:"id"`
+	Title  string    `json:"title"`
+	Slug   string    `json:"slug"`
+}
+
+// SeriesNavigation tells where a post sits in its series. Part and Total only
+// count the posts the viewer may read, and Previous and Next skip the others.
+type SeriesNavigation struct {
+	ID       uuid.UUID    `json:"id"`
+	Title    string       `json:"title"`
+	Part     int          `json:"part"`
+	Total    int          `json:"total"`
+	Previous *SeriesEntry `json:"previous"`
+	Next     *SeriesEntry `json:"next"`
+}
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (s *Series) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
+// Save creates or updates a series record.
+func (s *Series) Save(db *gorm.DB) error {
+	return db.Omit(clause.Associations).Save(s).Error
+}
+
+// FindByID retrieves a single series by its ID, preloading the author.
+func (s *Series) FindByID(db *gorm.DB, id uuid.UUID) (*Series, error) {
+	var series Series
+	err := db.Preload("User").Where("id = ?", id).First(&series).Error
+	return &series, err
+}
+
+// FindByIDForUpdate retrieves a series and locks its row until the surrounding transaction ends.
+func (s *Series) FindByIDForUpdate(tx *gorm.DB, id uuid.UUID) (*Series, error) {
+	var series Series
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&series).Error
+	return &series, err
+}
+
+// Delete removes the series. Its posts are kept and no longer belong to a series.
+func (s *Series) Delete(db *gorm.DB) error {
+	return db.Delete(s).Error
+}
+
+// Add appends the post to the end of the series.
+func (m *SeriesPost) Add(db *gorm.DB) error {
+	var next int
+	err := db.Model(&SeriesPost{}).Where("series_id = ?", m.SeriesID).Select("COALESCE(MAX(position) + 1, 0)").Scan(&next).Error
+	if err != nil {
+		return err
+	}
+
+	m.Position = next
+	return db.Create(m).Error
+}
+
+// FindByPostID retrieves the series membership of a post.
+func (m *SeriesPost) FindByPostID(db *gorm.DB, postID uuid.UUID) (*SeriesPost, error) {
+	var membership SeriesPost
+	err := db.Where("post_id = ?", postID).First(&membership).Error
+	return &membership, err
+}
+
+// FindAllBySeriesID retrieves every membership of a series in reading order,
+// including posts that are in the trash.
+func (m *SeriesPost) FindAllBySeriesID(db *gorm.DB, seriesID uuid.UUID) ([]SeriesPost, error) {
+	var memberships []SeriesPost
+	err := db.Where("series_id = ?", seriesID).Order("position, created_at").Find(&memberships).Error
+	return memberships, err
+}
+
+// UpdatePosition moves the post to a new place in the series.
+func (m *SeriesPost) UpdatePosition(db *gorm.DB, position int) error {
+	err := db.Model(&SeriesPost{}).Where("post_id = ?", m.PostID).Update("position", position).Error
+	if err != nil {
+		return err
+	}
+	m.Position = position
+	return nil
+}
+
+// Remove takes the post out of its series.
+func (m *SeriesPost) Remove(db *gorm.DB) error {
+	return db.Where("post_id = ?", m.PostID).Delete(&SeriesPost{}).Error
+}
+
+// inSeries joins posts to their series membership, in reading order.
+func inSeries(seriesID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN series_posts ON series_posts.post_id = posts.id AND series_posts.series_id = ?", seriesID).
+			Order("series_posts.position, series_posts.created_at")
+	}
+}
+
+// FindAllInSeries retrieves the posts of a series in reading order, preloading authors and attachments.
+func (p *Post) FindAllInSeries(db *gorm.DB, seriesID uuid.UUID) ([]Post, error) {
+	var posts []Post
+	err := db.Scopes(inSeries(seriesID)).Preload("User").Preload("Attachments", inDisplayOrder).Find(&posts).Error
+	return posts, err
+}
+
+// FindSeriesEntries retrieves the links to the posts of a series in reading order.
+func (p *Post) FindSeriesEntries(db *gorm.DB, seriesID uuid.UUID) ([]SeriesEntry, error) {
+	var entries []SeriesEntry
+	err := db.Model(&Post{}).Scopes(inSeries(seriesID)).
+		Select("posts.id AS post_id, posts.title, posts.slug").
+		Scan(&entries).Error
+	return entries, err
+}
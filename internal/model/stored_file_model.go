@@ -0,0 +1,135 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoredFile is uploaded content kept in storage once, however many records use it. SHA256 is the
+// hash of the uploaded content, so uploading the same bytes again finds the stored copy. RefCount
+// counts the records using the file; once it drops to zero the file is deleted in the background.
+// Status follows PostAttachment.Status: "local", then "cloud", or "failed" if the upload gave up,
+// or "quarantined" if the malware scanner flagged it.
+type StoredFile struct {
+	ID     uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	SHA256 string    `gorm:"column:sha256;type:char(64);not null;unique" json:"sha256"`
+	// ObjectName is the name the file is stored under, or that its sizes are named after
+	ObjectName string `gorm:"size:255;not null;unique" json:"object_name"`
+	// Variants lists the sizes stored for an image, comma-separated, like User.AvatarVariants
+	Variants    string    `gorm:"size:50;not null;default:''" json:"-"`
+	ContentType string    `gorm:"size:100;not null;default:''" json:"content_type"`
+	Size        int64     `gorm:"not null;default:0" json:"size"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"`
+	Status      string    `gorm:"size:20;not null;default:'local'" json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Upload states of a stored file.
+const (
+	StoredFileLocal       = "local"
+	StoredFileCloud       = "cloud"
+	StoredFileFailed      = "failed"
+	StoredFileQuarantined = "quarantined"
+)
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (f *StoredFile) BeforeCreate(tx *gorm.DB) (err error) {
+	f.ID = uuid.New()
+	return
+}
+
+// Objects returns the names of every stored object of the file.
+func (f *StoredFile) Objects() []string {
+	return variantObjects(f.ObjectName, f.Variants)
+}
+
+// Save creates or updates a stored file record.
+func (f *StoredFile) Save(db *gorm.DB) error {
+	return db.Save(f).Error
+}
+
+// FindByID retrieves a stored file by its ID.
+func (f *StoredFile) FindByID(db *gorm.DB, id uuid.UUID) (*StoredFile, error) {
+	var file StoredFile
+	err := db.Where("id = ?", id).First(&file).Error
+	return &file, err
+}
+
+// FindBySHA256ForUpdate retrieves the file holding the content with the given hash and locks it
+// until the transaction ends.
+func (f *StoredFile) FindBySHA256ForUpdate(tx *gorm.DB, sha256 string) (*StoredFile, error) {
+	var file StoredFile
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sha256 = ?", sha256).First(&file).Error
+	return &file, err
+}
+
+// FindByObjectNameForUpdate retrieves the file stored under the given name and locks it
+// until the transaction ends.
+func (f *StoredFile) FindByObjectNameForUpdate(tx *gorm.DB, objectName string) (*StoredFile, error) {
+	var file StoredFile
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("object_name = ?", objectName).First(&file).Error
+	return &file, err
+}
+
+// FindByIDForUpdate retrieves a stored file and locks it until the transaction ends.
+func (f *StoredFile) FindByIDForUpdate(tx *gorm.DB, id uuid.UUID) (*StoredFile, error) {
+	var file StoredFile
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&file).Error
+	return &file, err
+}
+
+// CreateOrReference records the file as local with one reference or, if content with the same
+// hash is already recorded, counts one more reference to that record instead. It reports whether
+// the file was created. Unlike a locking read, the upsert also serializes two first uploads of the
+// same content, which would otherwise both try to create it.
+func (f *StoredFile) CreateOrReference(tx *gorm.DB) (bool, error) {
+	f.RefCount = 1
+	f.Status = StoredFileLocal
+	result := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}),
+	}).Create(f)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	// MySQL counts a row updated instead of inserted twice
+	return result.RowsAffected == 1, nil
+}
+
+// Release counts one record fewer using the file. The update time records when it was last
+// released, which is when an unused file became unused.
+func (f *StoredFile) Release(db *gorm.DB) error {
+	return db.Model(&StoredFile{}).Where("id = ?", f.ID).Updates(map[string]interface{}{
+		"ref_count":  gorm.Expr("GREATEST(ref_count - 1, 0)"),
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// UpdateStatus records the progress of the file's upload.
+func (f *StoredFile) UpdateStatus(db *gorm.DB, status string) error {
+	return db.Model(&StoredFile{}).Where("id = ?", f.ID).Update("status", status).Error
+}
+
+// Delete removes the stored file record.
+func (f *StoredFile) Delete(db *gorm.DB) error {
+	return db.Where("id = ?", f.ID).Delete(&StoredFile{}).Error
+}
+
+// FindUnreferenced retrieves files no record has used since the given time, oldest first.
+func (f *StoredFile) FindUnreferenced(db *gorm.DB, before time.Time, limit int) ([]StoredFile, error) {
+	var files []StoredFile
+	err := db.Where("ref_count = 0 AND updated_at < ?", before).Order("updated_at").Limit(limit).Find(&files).Error
+	return files, err
+}
+
+// FindStalled retrieves stored files left mid-upload without a job.
+func (f *StoredFile) FindStalled(db *gorm.DB) ([]StoredFile, error) {
+	var files []StoredFile
+	err := db.Scopes(withoutUploadJob(UploadTargetStoredFile, "stored_files")).
+		Where("status = ?", StoredFileLocal).
+		Find(&files).Error
+	return files, err
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UploadJob is a file waiting to be moved from the server's disk to storage. Jobs
+// live in the database, so uploads survive restarts and failed attempts are retried.
+// Staged jobs are for files uploaded straight to storage: they wait under their held staging key,
+// with no local path, until they are scanned and moved to their object name.
+// A job is deleted once its upload succeeds; jobs that run out of attempts are kept as "dead",
+// and those whose file the malware scanner flagged as "quarantined".
+type UploadJob struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	TargetType    string    `gorm:"size:20;not null" json:"target_type"`
+	TargetID      uuid.UUID `gorm:"type:char(36);not null" json:"target_id"`
+	ObjectName    string    `gorm:"size:255;not null" json:"object_name"`
+	LocalPath     string    `gorm:"size:500;not null" json:"-"`
+	Staged        bool      `gorm:"not null;default:false" json:"staged"`
+	ContentType   string    `gorm:"size:100;not null;default:''" json:"content_type"`
+	Size          int64     `gorm:"not null;default:0" json:"size"`
+	Status        string    `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Attempts      int       `gorm:"not null;default:0" json:"attempts"`
+	LastError     string    `gorm:"size:1000;not null;default:''" json:"last_error"`
+	NextAttemptAt time.Time `gorm:"not null" json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// States of an upload job.
+const (
+	UploadJobPending     = "pending"
+	UploadJobRunning     = "running"
+	UploadJobDead        = "dead"
+	UploadJobQuarantined = "quarantined"
+)
+
+// Kinds of records an uploaded file belongs to.
+const (
+	UploadTargetAvatar     = "avatar"
+	UploadTargetAttachment = "attachment"
+	UploadTargetResumable  = "resumable"
+	UploadTargetStoredFile = "stored_file"
+)
+
+// maxUploadErrorLength matches the size of the last_error column.
+const maxUploadErrorLength = 1000
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (j *UploadJob) BeforeCreate(tx *gorm.DB) (err error) {
+	j.ID = uuid.New()
+	return
+}
+
+// Create adds a job that is due straight away.
+func (j *UploadJob) Create(db *gorm.DB) error {
+	j.Status = UploadJobPending
+	j.NextAttemptAt = time.Now()
+	return db.Create(j).Error
+}
+
+// FindDue retrieves pending jobs whose next attempt is due, oldest first.
+func (j *UploadJob) FindDue(db *gorm.DB, now time.Time, limit int) ([]UploadJob, error) {
+	var jobs []UploadJob
+	err := db.Where("status = ? AND next_attempt_at <= ?", UploadJobPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim marks a pending job as running and counts the attempt. It reports false
+// if the job was no longer pending, for example because another worker took it.
+func (j *UploadJob) Claim(db *gorm.DB) (bool, error) {
+	result := db.Model(&UploadJob{}).Where("id = ? AND status = ?", j.ID, UploadJobPending).
+		Updates(map[string]interface{}{"status": UploadJobRunning, "attempts": gorm.Expr("attempts + 1")})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	j.Status = UploadJobRunning
+	j.Attempts++
+	return true, nil
+}
+
+// Retry puts a failed job back in the queue until the given time.
+func (j *UploadJob) Retry(db *gorm.DB, nextAttemptAt time.Time, reason string) error {
+	return db.Model(&UploadJob{}).Where("id = ?", j.ID).Updates(map[string]interface{}{
+		"status":          UploadJobPending,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      truncateUploadError(reason),
+	}).Error
+}
+
+// Release puts a job that was interrupted back in the queue without counting the attempt.
+func (j *UploadJob) Release(db *gorm.DB) error {
+	return db.Model(&UploadJob{}).Where("id = ?", j.ID).Updates(map[string]interface{}{
+		"status":   UploadJobPending,
+		"attempts": gorm.Expr("GREATEST(attempts - 1, 0)"),
+	}).Error
+}
+
+// Kill gives up on a job for good.
+func (j *UploadJob) Kill(db *gorm.DB, reason string) error {
+	j.Status = UploadJobDead
+	return db.Model(&UploadJob{}).Where("id = ?", j.ID).Updates(map[string]interface{}{
+		"status":     UploadJobDead,
+		"last_error": truncateUploadError(reason),
+	}).Error
+}
+
+// Quarantine gives up on a job whose file is infected, recording where the file was moved to.
+func (j *UploadJob) Quarantine(db *gorm.DB, localPath, reason string) error {
+	j.Status = UploadJobQuarantined
+	j.LocalPath = localPath
+	return db.Model(&UploadJob{}).Where("id = ?", j.ID).Updates(map[string]interface{}{
+		"status":     UploadJobQuarantined,
+		"local_path": localPath,
+		"last_error": truncateUploadError(reason),
+	}).Error
+}
+
+// MoveLocalPath points the jobs of the file at oldPath to newPath, where it was moved to.
+func (j *UploadJob) MoveLocalPath(db *gorm.DB, oldPath, newPath string) error {
+	return db.Model(&UploadJob{}).Where("local_path = ?", oldPath).Update("local_path", newPath).Error
+}
+
+// Delete removes a finished job.
+func (j *UploadJob) Delete(db *gorm.DB) error {
+	return db.Where("id = ?", j.ID).Delete(&UploadJob{}).Error
+}
+
+// ResetRunning puts jobs that were running when the server stopped back in the queue.
+func (j *UploadJob) ResetRunning(db *gorm.DB) (int64, error) {
+	result := db.Model(&UploadJob{}).Where("status = ?", UploadJobRunning).
+		Updates(map[string]interface{}{"status": UploadJobPending, "next_attempt_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// CountOpen counts the other jobs still uploading any of the given files of a record.
+func (j *UploadJob) CountOpen(db *gorm.DB, targetID uuid.UUID, objectNames []string) (int64, error) {
+	var count int64
+	err := db.Model(&UploadJob{}).
+		Where("target_id = ? AND object_name IN ? AND status IN ? AND id <> ?",
+			targetID, objectNames, []string{UploadJobPending, UploadJobRunning}, j.ID).
+		Count(&count).Error
+	return count, err
+}
+
+// withoutUploadJob keeps records of the given target type that have no open upload job.
+func withoutUploadJob(targetType, table string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("NOT EXISTS (SELECT 1 FROM upload_jobs j WHERE j.target_type = ? AND j.target_id = "+table+".id AND j.status <> ?)", targetType, UploadJobDead)
+	}
+}
+
+// truncateUploadError fits an error message into the last_error column.
+// It cuts on a rune boundary, as MySQL rejects a split UTF-8 sequence.
+func truncateUploadError(reason string) string {
+	if len(reason) <= maxUploadErrorLength {
+		return reason
+	}
+	n := maxUploadErrorLength
+	for n > 0 && !utf8.RuneStart(reason[n]) {
+		n--
+	}
+	return reason[:n]
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UploadTicket lets a client send a file straight to storage through a signed link. The client
+// declares the file's size and SHA-256 checksum up front; completing the ticket checks the stored
+// object against them and links it to what the ticket is for. A ticket is deleted once completed.
+type UploadTicket struct {
+	ID             uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:char(36);not null" json:"user_id"`
+	Purpose        string     `gorm:"size:20;not null" json:"purpose"`
+	PostID         *uuid.UUID `gorm:"type:char(36)" json:"post_id,omitempty"`
+	ObjectName     string     `gorm:"size:255;not null" json:"object_name"`
+	OriginalName   string     `gorm:"size:255;not null;default:''" json:"original_name"`
+	ContentType    string     `gorm:"size:100;not null;default:''" json:"content_type"`
+	Size           int64      `gorm:"not null" json:"size"`
+	ChecksumSHA256 string     `gorm:"column:checksum_sha256;type:char(64);not null" json:"checksum_sha256"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// UploadURL is where the file is sent with PUT, set when the ticket is issued
+	UploadURL string `gorm:"-" json:"upload_url,omitempty"`
+
+	// Set when the ticket is completed, depending on its purpose
+	Attachment *PostAttachment `gorm:"-" json:"attachment,omitempty"`
+	User       *User           `gorm:"-" json:"user,omitempty"`
+}
+
+// What an upload ticket is for.
+const (
+	UploadTicketAvatar     = "avatar"
+	UploadTicketAttachment = "attachment"
+)
+
+// BeforeCreate is a GORM hook that runs before a new record is created.
+func (t *UploadTicket) BeforeCreate(tx *gorm.DB) (err error) {
+	t.ID = uuid.New()
+	return
+}
+
+// Create adds a new ticket.
+func (t *UploadTicket) Create(db *gorm.DB) error {
+	return db.Create(t).Error
+}
+
+// FindByID retrieves a ticket by its ID.
+func (t *UploadTicket) FindByID(db *gorm.DB, id uuid.UUID) (*UploadTicket, error) {
+	var ticket UploadTicket
+	err := db.Where("id = ?", id).First(&ticket).Error
+	return &ticket, err
+}
+
+// FindByObjectName retrieves the ticket a file is uploaded for.
+func (t *UploadTicket) FindByObjectName(db *gorm.DB, objectName string) (*UploadTicket, error) {
+	var ticket UploadTicket
+	err := db.Where("object_name = ?", objectName).First(&ticket).Error
+	return &ticket, err
+}
+
+// FindByIDForUpdate retrieves a ticket and locks it until the transaction ends.
+func (t *UploadTicket) FindByIDForUpdate(tx *gorm.DB, id uuid.UUID) (*UploadTicket, error) {
+	var ticket UploadTicket
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&ticket).Error
+	return &ticket, err
+}
+
+// Delete removes the ticket.
+func (t *UploadTicket) Delete(db *gorm.DB) error {
+	return db.Where("id = ?", t.ID).Delete(&UploadTicket{}).Error
+}
+
+// FindExpiredBefore retrieves tickets that expired before the given time, oldest first.
+func (t *UploadTicket) FindExpiredBefore(db *gorm.DB, before time.Time, limit int) ([]UploadTicket, error) {
+	var tickets []UploadTicket
+	err := db.Where("expires_at < ?", before).Order("expires_at").Limit(limit).Find(&tickets).Error
+	return tickets, err
+}
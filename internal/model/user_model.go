@@ -2,6 +2,9 @@ package model
 
 import (
 	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,12 +15,85 @@ import (
 type User struct {
 	ID          uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
 	Name        string    `gorm:"size:255;not null" json:"name"`
+	Username    string    `gorm:"size:30;unique" json:"username"`
 	Email       string    `gorm:"size:255;not null;unique" json:"email"`
 	Password    string    `gorm:"size:255;not null" json:"-"`
 	AvatarURL   string    `gorm:"size:255;null" json:"avatar_url,omitempty"`              // New field
 	ImageStatus string    `gorm:"size:20;not null;default:'default'" json:"image_status"` // New field
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// AvatarVariants lists the sizes stored for the avatar, comma-separated. Avatars uploaded
+	// before sizes were generated have none and are stored once, under AvatarURL.
+	AvatarVariants string `gorm:"size:50;not null;default:''" json:"-"`
+	// AvatarURLs maps each stored size of the avatar to where it is served, once it is in storage
+	AvatarURLs map[string]string `gorm:"-" json:"avatar_urls,omitempty"`
+
+	// Role and suspension are granted in the database or by moderators, never through Save
+	Role        string     `gorm:"size:20;->" json:"role"`
+	SuspendedAt *time.Time `gorm:"->" json:"suspended_at,omitempty"`
+}
+
+// User roles.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// IsModerator reports whether the user may review reports and take moderation actions.
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+// IsSuspended reports whether a moderator has suspended the user.
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// AvatarSizes returns the sizes stored for the avatar.
+func (u *User) AvatarSizes() []int {
+	return parseVariants(u.AvatarVariants)
+}
+
+// AvatarObjects returns the names of every stored file of the avatar.
+func (u *User) AvatarObjects() []string {
+	return variantObjects(u.AvatarURL, u.AvatarVariants)
+}
+
+// parseVariants reads a comma-separated list of stored sizes.
+func parseVariants(variants string) []int {
+	var sizes []int
+	for _, field := range strings.Split(variants, ",") {
+		if size, err := strconv.Atoi(field); err == nil {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// variantObjects returns the names a file is stored under in each of its sizes,
+// or just its own name when it is stored once.
+func variantObjects(name, variants string) []string {
+	if name == "" {
+		return nil
+	}
+	sizes := parseVariants(variants)
+	if len(sizes) == 0 {
+		return []string{name}
+	}
+
+	objects := make([]string, len(sizes))
+	for i, size := range sizes {
+		objects[i] = AvatarVariantName(name, size)
+	}
+	return objects
+}
+
+// AvatarVariantName returns the name one size of an avatar is stored under, e.g. abc_64.jpg for abc.jpg.
+func AvatarVariantName(avatar string, size int) string {
+	ext := filepath.Ext(avatar)
+	return strings.TrimSuffix(avatar, ext) + "_" + strconv.Itoa(size) + ext
 }
 
 // BeforeCreate is a GORM hook that runs before a new record is created.
@@ -47,14 +123,71 @@ func (u *User) FindByID(db *gorm.DB, id uuid.UUID) (*User, error) {
 	return &user, err
 }
 
+// FindSuspensionByID retrieves only the ID and suspension of a user, for the check made on every
+// authenticated request.
+func (u *User) FindSuspensionByID(db *gorm.DB, id uuid.UUID) (*User, error) {
+	var user User
+	err := db.Select("id", "suspended_at").Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
 // Delete removes a user record by their ID.
 func (u *User) Delete(db *gorm.DB, id uuid.UUID) error {
 	return db.WithContext(context.Background()).Where("id = ?", id).Delete(&User{}).Error
 }
 
+// Suspend marks the user as suspended.
+func (u *User) Suspend(db *gorm.DB) error {
+	now := time.Now()
+	if err := db.Model(&User{}).Where("id = ?", u.ID).UpdateColumn("suspended_at", now).Error; err != nil {
+		return err
+	}
+	u.SuspendedAt = &now
+	return nil
+}
+
+// UpdateImageStatus records the upload progress of an avatar, unless the user has replaced it since.
+func (u *User) UpdateImageStatus(db *gorm.DB, avatarURL, status string) error {
+	return db.Model(&User{}).Where("id = ? AND avatar_url = ?", u.ID, avatarURL).UpdateColumn("image_status", status).Error
+}
+
+// UpdateImageStatusByAvatar records the upload progress of an avatar for every user showing it.
+func (u *User) UpdateImageStatusByAvatar(db *gorm.DB, avatarURL, status string) error {
+	return db.Model(&User{}).Where("avatar_url = ?", avatarURL).UpdateColumn("image_status", status).Error
+}
+
+// FindByUsername retrieves a single user by their username.
+func (u *User) FindByUsername(db *gorm.DB, username string) (*User, error) {
+	var user User
+	err := db.WithContext(context.Background()).Where("username = ?", username).First(&user).Error
+	return &user, err
+}
+
+// FindAllByUsernames retrieves the users holding any of the given usernames.
+func (u *User) FindAllByUsernames(db *gorm.DB, usernames []string) ([]User, error) {
+	var users []User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := db.Where("username IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
 // FindByEmail is a custom finder method.
 func (u *User) FindByEmail(db *gorm.DB, email string) (*User, error) {
 	var user User
 	err := db.WithContext(context.Background()).Where("email = ?", email).First(&user).Error
 	return &user, err
 }
+
+// FindStalledAvatars retrieves users whose avatar was left mid-upload without a job,
+// which happens when the server stopped before the job was recorded. Avatars kept as
+// stored files are uploaded for the file rather than the user, so they are left out.
+func (u *User) FindStalledAvatars(db *gorm.DB) ([]User, error) {
+	var users []User
+	err := db.Scopes(withoutUploadJob(UploadTargetAvatar, "users")).
+		Where("image_status IN ? AND avatar_url <> ''", []string{"uploading", "local"}).
+		Where("NOT EXISTS (SELECT 1 FROM stored_files f WHERE f.object_name = users.avatar_url)").
+		Find(&users).Error
+	return users, err
+}
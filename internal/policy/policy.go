@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrForbidden is wrapped by every error that refuses an action, so callers can tell refusals apart with errors.Is.
+var ErrForbidden = errors.New("forbidden")
+
+// PostAction is something a user can do to an existing post.
+type PostAction string
+
+const (
+	ReadPost   PostAction = "read"   // see the post's collaborators; reading the post itself is left to model.ReadableBy
+	EditPost   PostAction = "edit"   // change the content, attachments and revisions
+	ManagePost PostAction = "manage" // change the visibility, delete, restore and manage collaborators
+)
+
+// postGrants lists the actions each role may take.
+var postGrants = map[string][]PostAction{
+	model.CollaboratorOwner:  {ReadPost, EditPost, ManagePost},
+	model.CollaboratorEditor: {ReadPost, EditPost},
+	model.CollaboratorViewer: {ReadPost},
+}
+
+// PostRole returns the user's role on a post: owner for its author, the role of an
+// accepted collaborator, or an empty string for anyone else.
+func PostRole(db *gorm.DB, post *model.Post, userID uuid.UUID) (string, error) {
+	if post.UserID == userID {
+		return model.CollaboratorOwner, nil
+	}
+
+	var collaborator model.PostCollaborator
+	return collaborator.FindRole(db, post.ID, userID)
+}
+
+// AuthorizePost checks that the user may take the action on the post.
+func AuthorizePost(db *gorm.DB, post *model.Post, userID uuid.UUID, action PostAction) error {
+	role, err := PostRole(db, post, userID)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(postGrants[role], action) {
+		return fmt.Errorf("%w: you cannot %s this post", ErrForbidden, action)
+	}
+	return nil
+}
+
+// AuthorizeSeries checks that the user owns the series. Only its author may change it.
+func AuthorizeSeries(series *model.Series, userID uuid.UUID) error {
+	if series.UserID != userID {
+		return fmt.Errorf("%w: you are not the owner of this series", ErrForbidden)
+	}
+	return nil
+}
+
+// RequireModerator checks that the user holds a moderation role.
+func RequireModerator(user *model.User) error {
+	if !user.IsModerator() {
+		return fmt.Errorf("%w: moderator role required", ErrForbidden)
+	}
+	return nil
+}
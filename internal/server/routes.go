@@ -1,18 +1,25 @@
 package server
 
 import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
 	"sync"
+	"time"
 	"venturo-core/configs"
 	"venturo-core/internal/handler/http"
 	"venturo-core/internal/middleware"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/scheduler"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 	"gorm.io/gorm"
 )
 
-func registerRoutes(app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.WaitGroup) {
+func registerRoutes(ctx context.Context, app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.WaitGroup) {
+	app.Use(middleware.NewBodyLimitMiddleware(fiber.DefaultBodyLimit, streamsBody))
 	app.Static("/public", "./public")
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
@@ -27,17 +34,59 @@ func registerRoutes(app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.
 	api := app.Group("/api/v1")
 
 	// --- Setups ---
-	authMiddleware := middleware.NewAuthMiddleware(conf.JWTSecretKey)
+	storageAdapter, err := NewStorageAdapter(conf)
+	if err != nil {
+		slog.Error("could not set up file storage", "driver", conf.StorageDriver, "error", err)
+		os.Exit(1)
+	}
+
+	fileScanner, err := newScanner(conf)
+	if err != nil {
+		slog.Error("could not set up malware scanning", "driver", conf.ScannerDriver, "error", err)
+		os.Exit(1)
+	}
 
 	// --- Setup services ---
 	authService := service.NewAuthService(db, conf)
-	userService := service.NewUserService(db, wg)
-	postService := service.NewPostService(db)
+	uploadQueue := service.NewUploadQueue(db, storageAdapter, fileScanner, conf)
+	storedFileService := service.NewStoredFileService(db, storageAdapter, uploadQueue)
+	userService := service.NewUserService(db, conf, wg, storageAdapter, uploadQueue, storedFileService)
+	notificationService := service.NewNotificationService(ctx, db)
+	attachmentService := service.NewAttachmentService(db, wg, storageAdapter, uploadQueue)
+	postService := service.NewPostService(db, conf, attachmentService, notificationService)
+	reactionService := service.NewReactionService(db, conf, notificationService)
+	feedService := service.NewFeedService(db, postService, conf)
+	followService := service.NewFollowService(db, notificationService)
+	bookmarkService := service.NewBookmarkService(db)
+	seriesService := service.NewSeriesService(db)
+	collaboratorService := service.NewCollaboratorService(db, notificationService)
+	analyticsService := service.NewAnalyticsService(ctx, db, conf, wg)
+	moderationService := service.NewModerationService(db, conf)
+	fileService := service.NewFileService(db, storageAdapter)
+	resumableUploadService := service.NewResumableUploadService(db, conf, storageAdapter, uploadQueue)
+	uploadTicketService := service.NewUploadTicketService(db, conf, storageAdapter, userService, attachmentService)
+	orphanCollector := service.NewOrphanCollector(db, conf, storageAdapter)
+
+	authMiddleware := middleware.NewAuthMiddleware(conf.JWTSecretKey, authService.IsActive)
+	optionalAuthMiddleware := middleware.NewOptionalAuthMiddleware(conf.JWTSecretKey, authService.IsActive)
 
 	// --- Setup handlers ---
 	authHandler := http.NewAuthHandler(authService)
 	userHandler := http.NewUserHandler(userService)
-	postHandler := http.NewPostHandler(postService)
+	postHandler := http.NewPostHandler(postService, analyticsService)
+	attachmentHandler := http.NewAttachmentHandler(attachmentService)
+	reactionHandler := http.NewReactionHandler(reactionService)
+	feedHandler := http.NewFeedHandler(feedService)
+	followHandler := http.NewFollowHandler(followService)
+	bookmarkHandler := http.NewBookmarkHandler(bookmarkService)
+	seriesHandler := http.NewSeriesHandler(seriesService)
+	collaboratorHandler := http.NewCollaboratorHandler(collaboratorService)
+	analyticsHandler := http.NewAnalyticsHandler(analyticsService)
+	moderationHandler := http.NewModerationHandler(moderationService)
+	notificationHandler := http.NewNotificationHandler(notificationService)
+	fileHandler := http.NewFileHandler(fileService)
+	resumableUploadHandler := http.NewResumableUploadHandler(resumableUploadService)
+	uploadTicketHandler := http.NewUploadTicketHandler(uploadTicketService)
 
 	// --- Auth routes ---
 	api.Post("/register", authHandler.Register)
@@ -46,12 +95,122 @@ func registerRoutes(app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.
 	// --- User routes ---
 	api.Get("/profile", authMiddleware, userHandler.GetProfile)
 	api.Put("/profile", authMiddleware, userHandler.UpdateProfile)
+	api.Post("/users/:id/follow", authMiddleware, followHandler.Follow)
+	api.Delete("/users/:id/follow", authMiddleware, followHandler.Unfollow)
 
 	// --- Register Post Routes ---
 	postRoutes := api.Group("/posts")
-	postRoutes.Get("/", postHandler.GetAllPosts)                      // Public
-	postRoutes.Get("/:id", postHandler.GetPostByID)                   // Public
-	postRoutes.Post("/", authMiddleware, postHandler.CreatePost)      // Protected
-	postRoutes.Put("/:id", authMiddleware, postHandler.UpdatePost)    // Protected
-	postRoutes.Delete("/:id", authMiddleware, postHandler.DeletePost) // Protected
+	postRoutes.Get("/", optionalAuthMiddleware, postHandler.GetAllPosts)                // Public
+	postRoutes.Get("/trash", authMiddleware, postHandler.GetTrash)                      // Protected
+	postRoutes.Get("/:id", optionalAuthMiddleware, postHandler.GetPostByID)             // Public
+	postRoutes.Get("/by-slug/:slug", optionalAuthMiddleware, postHandler.GetPostBySlug) // Public
+	postRoutes.Post("/", authMiddleware, postHandler.CreatePost)                        // Protected
+	postRoutes.Put("/:id", authMiddleware, postHandler.UpdatePost)                      // Protected
+	postRoutes.Delete("/:id", authMiddleware, postHandler.DeletePost)                   // Protected
+	postRoutes.Post("/:id/restore", authMiddleware, postHandler.RestorePost)            // Protected
+	postRoutes.Get("/:id/stats", authMiddleware, analyticsHandler.GetPostStats)         // Protected
+
+	// --- Register Post Revision Routes ---
+	postRoutes.Get("/:id/revisions", authMiddleware, postHandler.GetRevisions)                  // Protected
+	postRoutes.Get("/:id/revisions/diff", authMiddleware, postHandler.DiffRevisions)            // Protected
+	postRoutes.Post("/:id/revisions/:rev/restore", authMiddleware, postHandler.RestoreRevision) // Protected
+
+	// --- Register Post Attachment Routes ---
+	postRoutes.Post("/:id/attachments", authMiddleware, attachmentHandler.UploadAttachments)                // Protected
+	postRoutes.Put("/:id/attachments/order", authMiddleware, attachmentHandler.ReorderAttachments)          // Protected
+	postRoutes.Patch("/:id/attachments/:attachmentId", authMiddleware, attachmentHandler.UpdateAttachment)  // Protected
+	postRoutes.Delete("/:id/attachments/:attachmentId", authMiddleware, attachmentHandler.DeleteAttachment) // Protected
+
+	// --- Register Post Collaborator Routes ---
+	postRoutes.Get("/:id/collaborators", authMiddleware, collaboratorHandler.GetCollaborators)              // Protected
+	postRoutes.Post("/:id/collaborators", authMiddleware, collaboratorHandler.InviteCollaborator)           // Protected
+	postRoutes.Post("/:id/collaborators/accept", authMiddleware, collaboratorHandler.AcceptInvitation)      // Protected
+	postRoutes.Delete("/:id/collaborators/:userId", authMiddleware, collaboratorHandler.RemoveCollaborator) // Protected
+	api.Get("/collaborations/invitations", authMiddleware, collaboratorHandler.GetInvitations)              // Protected
+
+	// --- Register Post Reaction Routes ---
+	postRoutes.Put("/:id/reactions/:type", authMiddleware, reactionHandler.React)      // Protected
+	postRoutes.Delete("/:id/reactions/:type", authMiddleware, reactionHandler.Unreact) // Protected
+
+	// --- Register Moderation Routes ---
+	postRoutes.Post("/:id/report", authMiddleware, moderationHandler.ReportPost) // Protected
+	moderationRoutes := api.Group("/moderation", authMiddleware)
+	moderationRoutes.Get("/reports", moderationHandler.GetReportQueue)        // Moderators
+	moderationRoutes.Post("/posts/:id/actions", moderationHandler.TakeAction) // Moderators
+
+	// --- Register Series Routes ---
+	seriesRoutes := api.Group("/series")
+	seriesRoutes.Post("/", authMiddleware, seriesHandler.CreateSeries)                  // Protected
+	seriesRoutes.Get("/:id", optionalAuthMiddleware, seriesHandler.GetSeries)           // Public
+	seriesRoutes.Put("/:id", authMiddleware, seriesHandler.UpdateSeries)                // Protected
+	seriesRoutes.Delete("/:id", authMiddleware, seriesHandler.DeleteSeries)             // Protected
+	seriesRoutes.Post("/:id/posts", authMiddleware, seriesHandler.AddPost)              // Protected
+	seriesRoutes.Put("/:id/posts/order", authMiddleware, seriesHandler.ReorderPosts)    // Protected
+	seriesRoutes.Delete("/:id/posts/:postId", authMiddleware, seriesHandler.RemovePost) // Protected
+
+	// --- Register Bookmark Routes ---
+	bookmarkRoutes := api.Group("/bookmarks", authMiddleware)
+	bookmarkRoutes.Post("/", bookmarkHandler.AddBookmark)                       // Protected
+	bookmarkRoutes.Get("/", bookmarkHandler.GetBookmarks)                       // Protected
+	bookmarkRoutes.Delete("/", bookmarkHandler.RemoveBookmarks)                 // Protected
+	bookmarkRoutes.Get("/collections", bookmarkHandler.GetCollections)          // Protected
+	bookmarkRoutes.Post("/collections", bookmarkHandler.CreateCollection)       // Protected
+	bookmarkRoutes.Put("/collections/:id", bookmarkHandler.RenameCollection)    // Protected
+	bookmarkRoutes.Delete("/collections/:id", bookmarkHandler.DeleteCollection) // Protected
+
+	// --- Register Notification Routes ---
+	notificationRoutes := api.Group("/notifications", authMiddleware)
+	notificationRoutes.Get("/", notificationHandler.GetNotifications)             // Protected
+	notificationRoutes.Get("/stream", notificationHandler.Stream)                 // Protected
+	notificationRoutes.Post("/read-all", notificationHandler.MarkAllRead)         // Protected
+	notificationRoutes.Post("/:id/read", notificationHandler.MarkRead)            // Protected
+	notificationRoutes.Get("/preferences", notificationHandler.GetPreferences)    // Protected
+	notificationRoutes.Put("/preferences", notificationHandler.UpdatePreferences) // Protected
+
+	// --- Register Feed Routes ---
+	feedRoutes := api.Group("/feeds")
+	feedRoutes.Get("/posts/:format", feedHandler.GetSiteFeed)         // Public
+	feedRoutes.Get("/authors/:id/:format", feedHandler.GetAuthorFeed) // Public
+	feedRoutes.Get("/tags/:tag/:format", feedHandler.GetTagFeed)      // Public
+
+	// --- Register File Routes ---
+	api.Get("/files/:key", fileHandler.GetSignedFile) // Public, signed links only
+	api.Put("/files/:key", fileHandler.PutSignedFile) // Public, signed links only
+
+	// --- Register Resumable Upload Routes ---
+	// HEAD goes before GET, since Fiber also routes HEAD requests to GET handlers
+	uploadRoutes := api.Group("/uploads", resumableUploadHandler.TusResumable)
+	uploadRoutes.Options("/", resumableUploadHandler.GetServerOptions)                  // Public
+	uploadRoutes.Post("/", authMiddleware, resumableUploadHandler.CreateUpload)         // Protected
+	uploadRoutes.Head("/:id", authMiddleware, resumableUploadHandler.GetUploadOffset)   // Protected
+	uploadRoutes.Get("/:id", authMiddleware, resumableUploadHandler.GetUpload)          // Protected
+	uploadRoutes.Patch("/:id", authMiddleware, resumableUploadHandler.WriteChunk)       // Protected
+	uploadRoutes.Delete("/:id", authMiddleware, resumableUploadHandler.TerminateUpload) // Protected
+
+	// --- Register Upload Ticket Routes ---
+	ticketRoutes := api.Group("/upload-tickets", authMiddleware)
+	ticketRoutes.Post("/", uploadTicketHandler.CreateTicket)               // Protected
+	ticketRoutes.Post("/:id/complete", uploadTicketHandler.CompleteTicket) // Protected
+
+	// --- Background jobs ---
+	uploadQueue.Recover(ctx)
+	scheduler.RunEvery(ctx, wg, "process upload queue", conf.UploadPollInterval, uploadQueue.Process)
+	scheduler.RunEvery(ctx, wg, "purge trashed posts", time.Hour, postService.PurgeTrash)
+	scheduler.RunEvery(ctx, wg, "purge expired uploads", time.Hour, resumableUploadService.PurgeExpired)
+	scheduler.RunEvery(ctx, wg, "clean up upload tickets", time.Hour, uploadTicketService.CleanUpExpired)
+	scheduler.RunEvery(ctx, wg, "collect unreferenced files", time.Hour, storedFileService.CollectGarbage)
+	scheduler.RunEvery(ctx, wg, "collect orphaned files", conf.OrphanGCInterval, orphanCollector.Run)
+	scheduler.RunEvery(ctx, wg, "flush post views", conf.ViewFlushInterval, analyticsService.Flush)
+}
+
+// streamsBody reports whether a request goes to a handler that streams its body to disk, checking
+// its size itself, so the body limit does not apply.
+func streamsBody(c *fiber.Ctx) bool {
+	switch c.Method() {
+	case fiber.MethodPatch:
+		return strings.HasPrefix(c.Path(), "/api/v1/uploads/")
+	case fiber.MethodPut:
+		return strings.HasPrefix(c.Path(), "/api/v1/files/")
+	}
+	return false
 }
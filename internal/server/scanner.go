@@ -0,0 +1,23 @@
+package server
+
+import (
+	"fmt"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/scanner"
+)
+
+// newScanner creates the malware scanner selected by SCANNER_DRIVER.
+func newScanner(conf *configs.Config) (scanner.Scanner, error) {
+	switch conf.ScannerDriver {
+	case "none":
+		return scanner.NewNoopScanner(), nil
+	case "clamav":
+		return scanner.NewClamAVScanner(scanner.ClamAVOptions{
+			Address:  conf.ClamAVAddress,
+			Timeout:  conf.ClamAVTimeout,
+			MaxSize:  conf.ClamAVMaxSize,
+			Oversize: conf.ScannerOversize,
+		})
+	}
+	return nil, fmt.Errorf("unknown scanner driver %q", conf.ScannerDriver)
+}
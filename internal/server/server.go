@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"sync"
@@ -12,7 +13,8 @@ import (
 )
 
 // NewServer creates and configures a new Fiber application.
-func NewServer() (*fiber.App, *sync.WaitGroup) {
+// The returned cancel function stops the background jobs started by the routes.
+func NewServer() (*fiber.App, *sync.WaitGroup, context.CancelFunc) {
 	config, err := configs.LoadConfig()
 	if err != nil {
 		slog.Error("Failed to load configuration", "error", err)
@@ -21,18 +23,25 @@ func NewServer() (*fiber.App, *sync.WaitGroup) {
 
 	database.ConnectDB(&config)
 
-	app := fiber.New()
+	// Bodies are streamed, so uploads are never held in memory whole. The body limit
+	// middleware registered with the routes caps the bodies that are read whole.
+	app := fiber.New(fiber.Config{
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
 
 	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
 
-	registerRoutes(app, database.DB, &config, &wg)
+	registerRoutes(ctx, app, database.DB, &config, &wg)
 
-	return app, &wg
+	return app, &wg, cancel
 }
 
-func GracefulShutdown(app *fiber.App, wg *sync.WaitGroup) {
+func GracefulShutdown(app *fiber.App, wg *sync.WaitGroup, stopBackground context.CancelFunc) {
 	slog.Info("Gracefully shutting down...")
 	slog.Info("Waiting for background processes to finish...")
+	stopBackground()
 	wg.Wait()
 	slog.Info("All background processes finished.")
 
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fmt"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/storage"
+)
+
+// NewStorageAdapter creates the storage backend selected by STORAGE_DRIVER.
+func NewStorageAdapter(conf *configs.Config) (storage.StorageAdapter, error) {
+	switch conf.StorageDriver {
+	case "local":
+		return storage.NewLocalFSAdapter(storage.LocalFSOptions{
+			Root:        conf.StorageLocalRoot,
+			StagingRoot: conf.StorageStagingRoot,
+			PublicURL:   conf.StoragePublicURL,
+			SignedURL:   conf.StorageSignedURL,
+			SigningKey:  conf.StorageSignKey,
+		})
+	case "s3":
+		return storage.NewS3Adapter(storage.S3Options{
+			Endpoint:  conf.StorageS3Endpoint,
+			Region:    conf.StorageS3Region,
+			Bucket:    conf.StorageS3Bucket,
+			AccessKey: conf.StorageS3AccessKey,
+			SecretKey: conf.StorageS3SecretKey,
+			UseSSL:    conf.StorageS3UseSSL,
+			PathStyle: conf.StorageS3PathStyle,
+			PublicURL: conf.StoragePublicURL,
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", conf.StorageDriver)
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxStatsRange caps how many days a single stats request may cover.
+const maxStatsRange = 366
+
+// topReferrersLimit is how many referring sites the stats list.
+const topReferrersLimit = 20
+
+// finalFlushTimeout bounds the flush of buffered views at shutdown.
+const finalFlushTimeout = 5 * time.Second
+
+// Viewer identifies who viewed a post. Anonymous viewers are told apart by their
+// address and browser, which are hashed before anything is stored.
+type Viewer struct {
+	UserID    uuid.UUID // uuid.Nil for anonymous viewers
+	IP        string
+	UserAgent string
+	Referrer  string
+}
+
+// PostStats is the traffic of a post over a date range.
+type PostStats struct {
+	PostID         uuid.UUID                `json:"post_id"`
+	From           string                   `json:"from"`
+	To             string                   `json:"to"`
+	TotalViews     int64                    `json:"total_views"`
+	UniqueVisitors int64                    `json:"unique_visitors"`
+	Daily          []model.DailyViews       `json:"daily"`
+	Referrers      []model.PostViewReferrer `json:"referrers"`
+}
+
+// viewKey identifies a visitor of a post for de-duplication.
+type viewKey struct {
+	postID  uuid.UUID
+	visitor string
+}
+
+// dayKey identifies the traffic of a post on one day.
+type dayKey struct {
+	postID uuid.UUID
+	day    time.Time
+}
+
+// pendingViews is the traffic of a post on one day that has not been written yet.
+type pendingViews struct {
+	views     int64
+	visitors  map[string]struct{}
+	referrers map[string]int64
+}
+
+type AnalyticsService struct {
+	db     *gorm.DB
+	window time.Duration
+
+	mu      sync.Mutex
+	seen    map[viewKey]time.Time
+	pending map[dayKey]*pendingViews
+}
+
+// NewAnalyticsService creates a new analytics service. Views are buffered in memory and written
+// by Flush; whatever is still buffered when ctx is cancelled is flushed once more before wg is released.
+func NewAnalyticsService(ctx context.Context, db *gorm.DB, conf *configs.Config, wg *sync.WaitGroup) *AnalyticsService {
+	s := &AnalyticsService{
+		db:      db,
+		window:  conf.ViewDedupeWindow,
+		seen:    make(map[viewKey]time.Time),
+		pending: make(map[dayKey]*pendingViews),
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+
+		flushCtx, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+		defer cancel()
+		s.Flush(flushCtx)
+	}()
+	return s
+}
+
+// RecordView counts a view of a post. Repeated views by the same visitor within the
+// de-duplication window count once, and authors viewing their own posts are not counted.
+// The view is only buffered; it reaches the database with the next Flush.
+func (s *AnalyticsService) RecordView(post *model.Post, viewer Viewer) {
+	if viewer.UserID != uuid.Nil && viewer.UserID == post.UserID {
+		return
+	}
+
+	visitor := visitorHash(viewer)
+	now := time.Now()
+	key := viewKey{postID: post.ID, visitor: visitor}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if last, ok := s.seen[key]; ok && now.Sub(last) < s.window {
+		return
+	}
+	s.seen[key] = now
+
+	day := dayKey{postID: post.ID, day: startOfDay(now)}
+	views, ok := s.pending[day]
+	if !ok {
+		views = &pendingViews{visitors: make(map[string]struct{}), referrers: make(map[string]int64)}
+		s.pending[day] = views
+	}
+	views.views++
+	views.visitors[visitor] = struct{}{}
+	views.referrers[referrerHost(viewer.Referrer)]++
+}
+
+// Flush writes the buffered views to the database in one transaction.
+// If the write fails, the views are kept for the next attempt.
+func (s *AnalyticsService) Flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[dayKey]*pendingViews)
+
+	// Visitors outside the window no longer need remembering
+	now := time.Now()
+	for key, last := range s.seen {
+		if now.Sub(last) >= s.window {
+			delete(s.seen, key)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := s.write(ctx, pending); err != nil {
+		slog.Error("Failed to flush post views", "error", err)
+		s.requeue(pending)
+		return
+	}
+	slog.Info("Flushed post views", "days", len(pending))
+}
+
+// write stores buffered views, skipping posts that were purged in the meantime.
+func (s *AnalyticsService) write(ctx context.Context, pending map[dayKey]*pendingViews) error {
+	postIDs := make([]uuid.UUID, 0, len(pending))
+	seenPost := make(map[uuid.UUID]bool)
+	for key := range pending {
+		if !seenPost[key.postID] {
+			seenPost[key.postID] = true
+			postIDs = append(postIDs, key.postID)
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post model.Post
+		existingIDs, err := post.FindExistingIDs(tx, postIDs)
+		if err != nil {
+			return err
+		}
+		exists := make(map[uuid.UUID]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			exists[id] = true
+		}
+
+		var stats []model.PostViewStat
+		var visitors []model.PostViewVisitor
+		var referrers []model.PostViewReferrer
+		for key, views := range pending {
+			if !exists[key.postID] {
+				continue
+			}
+			stats = append(stats, model.PostViewStat{PostID: key.postID, Day: key.day, Views: views.views})
+			for visitor := range views.visitors {
+				visitors = append(visitors, model.PostViewVisitor{PostID: key.postID, Day: key.day, Visitor: visitor})
+			}
+			for referrer, count := range views.referrers {
+				referrers = append(referrers, model.PostViewReferrer{PostID: key.postID, Day: key.day, Referrer: referrer, Views: count})
+			}
+		}
+
+		var stat model.PostViewStat
+		if err := stat.AddMany(tx, stats); err != nil {
+			return err
+		}
+		var visitor model.PostViewVisitor
+		if err := visitor.AddMany(tx, visitors); err != nil {
+			return err
+		}
+		var referrer model.PostViewReferrer
+		return referrer.AddMany(tx, referrers)
+	})
+}
+
+// requeue merges views that could not be written back into the buffer.
+func (s *AnalyticsService) requeue(failed map[dayKey]*pendingViews) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, views := range failed {
+		current, ok := s.pending[key]
+		if !ok {
+			s.pending[key] = views
+			continue
+		}
+		current.views += views.views
+		for visitor := range views.visitors {
+			current.visitors[visitor] = struct{}{}
+		}
+		for referrer, count := range views.referrers {
+			current.referrers[referrer] += count
+		}
+	}
+}
+
+// GetPostStats retrieves the daily views, unique visitors and top referrers of a post
+// over the days from and to, inclusive. Only the author and co-owners can see them.
+// Views from the last few seconds may not be included until they are flushed.
+func (s *AnalyticsService) GetPostStats(postID, userID uuid.UUID, from, to time.Time) (*PostStats, error) {
+	from, to = startOfDay(from), startOfDay(to)
+	if to.Before(from) {
+		return nil, errors.New("invalid range: from must not be after to")
+	}
+	if to.Sub(from) >= maxStatsRange*24*time.Hour {
+		return nil, errors.New("invalid range: at most 366 days can be requested")
+	}
+
+	var found model.Post
+	post, err := found.FindByID(s.db, postID)
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+	if err := policy.AuthorizePost(s.db, post, userID, policy.ManagePost); err != nil {
+		return nil, err
+	}
+
+	var stat model.PostViewStat
+	recorded, err := stat.FindDaily(s.db, postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var visitor model.PostViewVisitor
+	unique, err := visitor.CountUnique(s.db, postID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var referrer model.PostViewReferrer
+	referrers, err := referrer.FindTop(s.db, postID, from, to, topReferrersLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Days without traffic are listed with zeros, so the series has no gaps
+	byDay := make(map[string]model.DailyViews, len(recorded))
+	for _, day := range recorded {
+		byDay[day.Day.Format(time.DateOnly)] = day
+	}
+	stats := &PostStats{
+		PostID:         postID,
+		From:           from.Format(time.DateOnly),
+		To:             to.Format(time.DateOnly),
+		UniqueVisitors: unique,
+		Referrers:      referrers,
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		daily, ok := byDay[day.Format(time.DateOnly)]
+		if !ok {
+			daily = model.DailyViews{Day: day}
+		}
+		stats.TotalViews += daily.Views
+		stats.Daily = append(stats.Daily, daily)
+	}
+	return stats, nil
+}
+
+// startOfDay returns midnight of t's day in the server's time zone, which is the zone days are stored in.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.In(time.Local).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+// visitorHash turns a viewer into an opaque visitor ID.
+func visitorHash(viewer Viewer) string {
+	identity := "anon:" + viewer.IP + "|" + viewer.UserAgent
+	if viewer.UserID != uuid.Nil {
+		identity = "user:" + viewer.UserID.String()
+	}
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:])
+}
+
+// referrerHost reduces a Referer header to the host that sent the visitor.
+func referrerHost(referrer string) string {
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Hostname() == "" {
+		return model.DirectReferrer
+	}
+
+	host := strings.ToLower(strings.TrimPrefix(parsed.Hostname(), "www."))
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	return host
+}
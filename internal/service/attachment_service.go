@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"path/filepath"
+	"sync"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+	"venturo-core/pkg/uploader"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// attachmentUploadPath is the temporary local storage path for attachments. It is kept out of
+// ./public, so files cannot be downloaded before they have been scanned.
+const attachmentUploadPath = "./uploads/attachments"
+
+// maxAttachmentsPerUpload caps the number of files accepted in a single request.
+const maxAttachmentsPerUpload = 10
+
+// maxAltTextLength matches the size of the alt_text column.
+const maxAltTextLength = 500
+
+type AttachmentService struct {
+	db       *gorm.DB
+	uploader *uploader.FileUploader
+	queue    *UploadQueue
+	wg       *sync.WaitGroup
+}
+
+// NewAttachmentService creates a new attachment service.
+func NewAttachmentService(db *gorm.DB, wg *sync.WaitGroup, storageAdapter storage.StorageAdapter, queue *UploadQueue) *AttachmentService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, attachmentUploadPath)
+	s := &AttachmentService{db: db, uploader: fileUploader, queue: queue, wg: wg}
+	queue.Register(model.UploadTargetAttachment, UploadTarget{
+		Done:        attachmentStatusUpdater(model.AttachmentStatusCloud),
+		Failed:      attachmentStatusUpdater(model.AttachmentStatusFailed),
+		Quarantined: attachmentStatusUpdater(model.AttachmentStatusQuarantined),
+		Stalled:     s.stalledAttachments,
+	})
+	return s
+}
+
+// UploadAttachments adds files to the end of a post's attachments. altTexts are matched to files by index.
+// The files are saved on the server before this returns, and the records come back with status "local";
+// the upload queue then moves the files to storage in the background.
+func (s *AttachmentService) UploadAttachments(postID, userID uuid.UUID, files []*multipart.FileHeader, altTexts []string) ([]model.PostAttachment, error) {
+	if len(files) == 0 {
+		return nil, errors.New("at least one file is required")
+	}
+	if len(files) > maxAttachmentsPerUpload {
+		return nil, fmt.Errorf("at most %d files can be uploaded at once", maxAttachmentsPerUpload)
+	}
+	for _, altText := range altTexts {
+		if len(altText) > maxAltTextLength {
+			return nil, fmt.Errorf("alt text must be at most %d characters long", maxAltTextLength)
+		}
+	}
+
+	attachments := make([]model.PostAttachment, len(files))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the post so concurrent uploads get distinct positions
+		if _, err := findEditablePostForUpdate(tx, postID, userID); err != nil {
+			return err
+		}
+
+		var attachment model.PostAttachment
+		position, err := attachment.NextPosition(tx, postID)
+		if err != nil {
+			return err
+		}
+
+		for i, file := range files {
+			attachments[i] = model.PostAttachment{
+				PostID:       postID,
+				FileName:     uuid.New().String() + filepath.Ext(file.Filename),
+				OriginalName: filepath.Base(file.Filename),
+				ContentType:  file.Header.Get("Content-Type"),
+				Size:         file.Size,
+				Position:     position + i,
+				Status:       model.AttachmentStatusUploading,
+			}
+			if i < len(altTexts) {
+				attachments[i].AltText = altTexts[i]
+			}
+			if err := attachments[i].Save(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, file := range files {
+		s.startUpload(&attachments[i], file)
+	}
+	return attachments, nil
+}
+
+// AddStoredAttachment adds a file that was uploaded straight to storage to the end of a post's
+// attachments. Pass the transaction that hands the file over, so the two commit together. The file
+// stays under its held key and the record comes back with status "local"; the upload queue then
+// scans the file and moves it into place in the background.
+func (s *AttachmentService) AddStoredAttachment(tx *gorm.DB, postID, userID uuid.UUID, attachment *model.PostAttachment) error {
+	if len(attachment.AltText) > maxAltTextLength {
+		return fmt.Errorf("alt text must be at most %d characters long", maxAltTextLength)
+	}
+	if _, err := findEditablePostForUpdate(tx, postID, userID); err != nil {
+		return err
+	}
+
+	position, err := attachment.NextPosition(tx, postID)
+	if err != nil {
+		return err
+	}
+	attachment.PostID = postID
+	attachment.Position = position
+	attachment.Status = model.AttachmentStatusLocal
+	if err := attachment.Save(tx); err != nil {
+		return err
+	}
+	return s.queue.Enqueue(tx, &model.UploadJob{
+		TargetType:  model.UploadTargetAttachment,
+		TargetID:    attachment.ID,
+		ObjectName:  attachment.FileName,
+		Staged:      true,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+	})
+}
+
+// startUpload saves the file on the server and queues its move to storage,
+// tracking its progress on the attachment's status.
+func (s *AttachmentService) startUpload(attachment *model.PostAttachment, file *multipart.FileHeader) {
+	localPath, err := s.uploader.SaveLocalFile(file, attachment.FileName)
+	if err != nil {
+		slog.Error("Error saving temp file", "file", attachment.FileName, "error", err)
+		attachment.Status = model.AttachmentStatusFailed
+		if err := attachment.UpdateStatus(s.db, attachment.Status); err != nil {
+			slog.Error("Error updating status to 'failed' for attachment", "attachmentID", attachment.ID, "error", err)
+		}
+		return
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := attachment.UpdateStatus(tx, model.AttachmentStatusLocal); err != nil {
+			return err
+		}
+		return s.queue.Enqueue(tx, &model.UploadJob{
+			TargetType:  model.UploadTargetAttachment,
+			TargetID:    attachment.ID,
+			ObjectName:  attachment.FileName,
+			LocalPath:   localPath,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		})
+	})
+	if err != nil {
+		// The record stays "uploading", so the queue picks the file up when it next recovers
+		slog.Error("Error queueing upload for attachment", "attachmentID", attachment.ID, "error", err)
+		return
+	}
+	attachment.Status = model.AttachmentStatusLocal
+}
+
+// attachmentStatusUpdater records the outcome of an attachment upload.
+func attachmentStatusUpdater(status string) func(tx *gorm.DB, job *model.UploadJob) error {
+	return func(tx *gorm.DB, job *model.UploadJob) error {
+		attachment := model.PostAttachment{ID: job.TargetID}
+		return attachment.UpdateStatus(tx, status)
+	}
+}
+
+// stalledAttachments lists the attachment uploads the upload queue lost track of.
+func (s *AttachmentService) stalledAttachments(db *gorm.DB) ([]model.UploadJob, error) {
+	var found model.PostAttachment
+	attachments, err := found.FindStalled(db)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]model.UploadJob, len(attachments))
+	for i, attachment := range attachments {
+		jobs[i] = model.UploadJob{
+			TargetID:    attachment.ID,
+			ObjectName:  attachment.FileName,
+			LocalPath:   s.uploader.LocalPath(attachment.FileName),
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+		}
+	}
+	return jobs, nil
+}
+
+// UpdateAltText changes the alt text of one of the post's attachments.
+func (s *AttachmentService) UpdateAltText(postID, attachmentID, userID uuid.UUID, altText string) (*model.PostAttachment, error) {
+	if len(altText) > maxAltTextLength {
+		return nil, fmt.Errorf("alt text must be at most %d characters long", maxAltTextLength)
+	}
+
+	var attachment *model.PostAttachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findEditablePostForUpdate(tx, postID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		if attachment, err = new(model.PostAttachment).FindByID(tx, postID, attachmentID); err != nil {
+			return errors.New("attachment not found")
+		}
+		return attachment.UpdateAltText(tx, altText)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// ReorderAttachments sets the display order of a post's attachments.
+// attachmentIDs must list every attachment of the post exactly once.
+func (s *AttachmentService) ReorderAttachments(postID, userID uuid.UUID, attachmentIDs []uuid.UUID) ([]model.PostAttachment, error) {
+	var attachments []model.PostAttachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findEditablePostForUpdate(tx, postID, userID); err != nil {
+			return err
+		}
+
+		var attachment model.PostAttachment
+		current, err := attachment.FindAllByPostID(tx, postID)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[uuid.UUID]*model.PostAttachment, len(current))
+		for i := range current {
+			byID[current[i].ID] = &current[i]
+		}
+		if len(attachmentIDs) != len(current) {
+			return errors.New("invalid order: every attachment of the post must be listed exactly once")
+		}
+
+		attachments = make([]model.PostAttachment, 0, len(attachmentIDs))
+		for position, id := range attachmentIDs {
+			item, ok := byID[id]
+			if !ok {
+				return errors.New("invalid order: every attachment of the post must be listed exactly once")
+			}
+			delete(byID, id) // A repeated ID will not be found a second time
+
+			if item.Position != position {
+				if err := item.UpdatePosition(tx, position); err != nil {
+					return err
+				}
+			}
+			attachments = append(attachments, *item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment removes one of the post's attachments and its stored file.
+func (s *AttachmentService) DeleteAttachment(postID, attachmentID, userID uuid.UUID) error {
+	var attachment *model.PostAttachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findEditablePostForUpdate(tx, postID, userID); err != nil {
+			return err
+		}
+
+		var err error
+		if attachment, err = new(model.PostAttachment).FindByID(tx, postID, attachmentID); err != nil {
+			return errors.New("attachment not found")
+		}
+		return attachment.Delete(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The file goes only once the record is gone for good
+	s.DeleteFiles([]model.PostAttachment{*attachment})
+	return nil
+}
+
+// DeleteFiles removes the stored files of attachments whose records are already gone.
+// It runs in the background, alongside the uploads.
+func (s *AttachmentService) DeleteFiles(attachments []model.PostAttachment) {
+	if len(attachments) == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for _, attachment := range attachments {
+			if err := s.uploader.Delete(context.Background(), attachment.FileName); err != nil {
+				slog.Error("Error deleting attachment file", "file", attachment.FileName, "error", err)
+			}
+		}
+	}()
+}
+
+// findEditablePostForUpdate locks a post and ensures the given user may edit it.
+// It must run inside a transaction.
+func findEditablePostForUpdate(db *gorm.DB, postID, userID uuid.UUID) (*model.Post, error) {
+	post, err := new(model.Post).FindByIDForUpdate(db, postID)
+	if err != nil {
+		return nil, err // Post not found
+	}
+
+	if err := policy.AuthorizePost(db, post, userID, policy.EditPost); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
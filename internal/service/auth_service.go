@@ -3,10 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
 	"venturo-core/configs"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/mention"
 	"venturo-core/pkg/utils"
 
+	"github.com/google/uuid"
+	"github.com/gosimple/slug"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -21,14 +27,23 @@ func NewAuthService(db *gorm.DB, conf *configs.Config) *AuthService {
 	return &AuthService{db: db, conf: conf}
 }
 
-// Register creates a new user.
-func (s *AuthService) Register(ctx context.Context, name, email, password string) error {
+// Register creates a new user. An empty username is generated from the name.
+func (s *AuthService) Register(ctx context.Context, name, username, email, password string) error {
 	// Check if user already exists
 	var existingUser model.User
 	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&existingUser).Error; err == nil {
 		return errors.New("user with this email already exists")
 	}
 
+	if username == "" {
+		username = s.generateUsername(ctx, name)
+	} else {
+		username = mention.Normalize(username)
+		if _, err := existingUser.FindByUsername(s.db.WithContext(ctx), username); err == nil {
+			return errors.New("username is already taken")
+		}
+	}
+
 	// Hash the password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -38,6 +53,7 @@ func (s *AuthService) Register(ctx context.Context, name, email, password string
 	// Create new user
 	newUser := model.User{
 		Name:     name,
+		Username: username,
 		Email:    email,
 		Password: string(hashedPassword),
 	}
@@ -50,6 +66,41 @@ func (s *AuthService) Register(ctx context.Context, name, email, password string
 	return nil
 }
 
+// generateUsername derives a free username from a display name, such as "jane_doe" or "jane_doe_4821".
+func (s *AuthService) generateUsername(ctx context.Context, name string) string {
+	base := strings.ReplaceAll(slug.Make(name), "-", "_")
+	if len(base) > 24 {
+		base = strings.TrimRight(base[:24], "_")
+	}
+	if !mention.ValidUsername(base) {
+		base = "user"
+	}
+
+	var user model.User
+	candidate := base
+	for attempt := 0; attempt < 10; attempt++ {
+		if _, err := user.FindByUsername(s.db.WithContext(ctx), candidate); err != nil {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, rand.IntN(10000))
+	}
+	// Fall back to a random suffix long enough to be practically unique
+	return fmt.Sprintf("%s_%s", base, strings.ReplaceAll(uuid.New().String(), "-", "")[:5])
+}
+
+// IsActive reports whether the user a token was issued to may still use it: tokens of suspended
+// and deleted users are turned away, even though they have not expired yet.
+func (s *AuthService) IsActive(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := new(model.User).FindSuspensionByID(s.db.WithContext(ctx), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !user.IsSuspended(), nil
+}
+
 // Login validates user credentials and returns a JWT.
 func (s *AuthService) Login(ctx context.Context, email, password string) (string, error) {
 	// Find user by email
@@ -64,6 +115,10 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (string
 		return "", errors.New("invalid credentials")
 	}
 
+	if user.IsSuspended() {
+		return "", errors.New("account suspended")
+	}
+
 	// Generate JWT
 	token, err := utils.GenerateToken(user.ID, s.conf.JWTSecretKey)
 	if err != nil {
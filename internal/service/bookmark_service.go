@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/cursor"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxBulkBookmarkRemoval caps the number of bookmarks removed in a single request.
+const maxBulkBookmarkRemoval = 100
+
+type BookmarkService struct {
+	db *gorm.DB
+}
+
+// NewBookmarkService creates a new bookmark service.
+func NewBookmarkService(db *gorm.DB) *BookmarkService {
+	return &BookmarkService{db: db}
+}
+
+// AddBookmark saves a post for the user, optionally in one of their collections.
+// Bookmarking an already bookmarked post moves it to the given collection.
+func (s *BookmarkService) AddBookmark(userID, postID uuid.UUID, collectionID *uuid.UUID) (*model.Bookmark, error) {
+	var post model.Post
+	if _, err := post.FindByID(s.db.Scopes(model.ReadableBy(userID)), postID); err != nil {
+		return nil, errors.New("post not found")
+	}
+
+	if collectionID != nil {
+		var collection model.BookmarkCollection
+		if _, err := collection.FindByID(s.db, userID, *collectionID); err != nil {
+			return nil, errors.New("collection not found")
+		}
+	}
+
+	bookmark := model.Bookmark{UserID: userID, PostID: postID, CollectionID: collectionID}
+	if err := bookmark.Save(s.db); err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// GetBookmarks retrieves a page of the user's bookmarks, newest first, starting after the given cursor.
+// The returned cursor points to the next page and is empty on the last one.
+func (s *BookmarkService) GetBookmarks(userID uuid.UUID, collectionID *uuid.UUID, after string, limit int) ([]model.Bookmark, string, error) {
+	query := model.BookmarkQuery{
+		UserID:       userID,
+		CollectionID: collectionID,
+		Limit:        limit + 1, // One extra row tells whether another page follows
+	}
+	if after != "" {
+		var err error
+		if query.AfterTime, query.AfterPostID, err = cursor.Decode(after); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var bookmark model.Bookmark
+	bookmarks, err := bookmark.FindPage(s.db, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(bookmarks) > limit {
+		bookmarks = bookmarks[:limit]
+		last := bookmarks[limit-1]
+		next = cursor.Encode(last.CreatedAt, last.PostID)
+	}
+
+	bookmarked := true
+	for i := range bookmarks {
+		bookmarks[i].Post.IsBookmarked = &bookmarked
+	}
+	return bookmarks, next, nil
+}
+
+// RemoveBookmarks deletes the user's bookmarks of the given posts and returns how many were removed.
+func (s *BookmarkService) RemoveBookmarks(userID uuid.UUID, postIDs []uuid.UUID) (int64, error) {
+	if len(postIDs) == 0 || len(postIDs) > maxBulkBookmarkRemoval {
+		return 0, fmt.Errorf("invalid post_ids: between 1 and %d posts can be removed at once", maxBulkBookmarkRemoval)
+	}
+
+	var bookmark model.Bookmark
+	return bookmark.RemoveMany(s.db, userID, postIDs)
+}
+
+// GetCollections lists the user's bookmark collections.
+func (s *BookmarkService) GetCollections(userID uuid.UUID) ([]model.BookmarkCollection, error) {
+	var collection model.BookmarkCollection
+	return collection.FindAllByUser(s.db, userID)
+}
+
+// CreateCollection creates a new bookmark collection for the user.
+func (s *BookmarkService) CreateCollection(userID uuid.UUID, name string) (*model.BookmarkCollection, error) {
+	if s.nameTaken(userID, uuid.Nil, name) {
+		return nil, errors.New("a collection with this name already exists")
+	}
+
+	collection := model.BookmarkCollection{UserID: userID, Name: name}
+	if err := collection.Save(s.db); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// RenameCollection changes the name of one of the user's collections.
+func (s *BookmarkService) RenameCollection(userID, collectionID uuid.UUID, name string) (*model.BookmarkCollection, error) {
+	var found model.BookmarkCollection
+	collection, err := found.FindByID(s.db, userID, collectionID)
+	if err != nil {
+		return nil, errors.New("collection not found")
+	}
+
+	if s.nameTaken(userID, collectionID, name) {
+		return nil, errors.New("a collection with this name already exists")
+	}
+
+	collection.Name = name
+	if err := collection.Save(s.db); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// DeleteCollection removes one of the user's collections. Its bookmarks are kept, without a collection.
+func (s *BookmarkService) DeleteCollection(userID, collectionID uuid.UUID) error {
+	var found model.BookmarkCollection
+	collection, err := found.FindByID(s.db, userID, collectionID)
+	if err != nil {
+		return errors.New("collection not found")
+	}
+	return collection.Delete(s.db)
+}
+
+// nameTaken reports whether another of the user's collections already uses the name.
+func (s *BookmarkService) nameTaken(userID, exceptID uuid.UUID, name string) bool {
+	var found model.BookmarkCollection
+	existing, err := found.FindByName(s.db, userID, name)
+	return err == nil && existing.ID != exceptID
+}
+
+// markBookmarked sets IsBookmarked on each post for the given user.
+func markBookmarked(db *gorm.DB, userID uuid.UUID, posts []*model.Post) error {
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	var bookmark model.Bookmark
+	bookmarked, err := bookmark.FindBookmarkedPostIDs(db, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		isBookmarked := bookmarked[post.ID]
+		post.IsBookmarked = &isBookmarked
+	}
+	return nil
+}
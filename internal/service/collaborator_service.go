@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CollaboratorService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+// NewCollaboratorService creates a new collaborator service.
+func NewCollaboratorService(db *gorm.DB, notifications *NotificationService) *CollaboratorService {
+	return &CollaboratorService{db: db, notifications: notifications}
+}
+
+// GetCollaborators lists the collaborators of a post, including pending invitations.
+// Only the author and the post's collaborators can see them.
+func (s *CollaboratorService) GetCollaborators(postID, userID uuid.UUID) ([]model.PostCollaborator, error) {
+	var found model.Post
+	post, err := found.FindByID(s.db, postID)
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+
+	if err := policy.AuthorizePost(s.db, post, userID, policy.ReadPost); err != nil {
+		return nil, err
+	}
+
+	var collaborator model.PostCollaborator
+	return collaborator.FindAllByPostID(s.db, postID)
+}
+
+// InviteCollaborator invites a user to collaborate on a post with the given role and notifies them.
+// Inviting a user who is already invited or collaborating changes their role instead.
+func (s *CollaboratorService) InviteCollaborator(postID, inviterID, inviteeID uuid.UUID, role string) (*model.PostCollaborator, error) {
+	err := s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		post, err := new(model.Post).FindByIDForUpdate(tx, postID)
+		if err != nil {
+			return errors.New("post not found")
+		}
+
+		if err := policy.AuthorizePost(tx, post, inviterID, policy.ManagePost); err != nil {
+			return err
+		}
+		if inviteeID == post.UserID {
+			return errors.New("cannot invite the author of the post")
+		}
+
+		var user model.User
+		if _, err := user.FindByID(tx, inviteeID); err != nil {
+			return errors.New("user not found")
+		}
+
+		var found model.PostCollaborator
+		if existing, err := found.FindByUser(tx, postID, inviteeID); err == nil {
+			return existing.UpdateRole(tx, role)
+		}
+
+		collaborator := model.PostCollaborator{
+			PostID:    postID,
+			UserID:    inviteeID,
+			Role:      role,
+			Status:    model.CollaborationPending,
+			InvitedBy: &inviterID,
+		}
+		if err := collaborator.Create(tx); err != nil {
+			return err
+		}
+
+		return batch.Notify(&model.Notification{
+			UserID:     inviteeID,
+			ActorID:    &inviterID,
+			Type:       model.NotificationInvite,
+			TargetType: model.NotificationTargetPost,
+			TargetID:   postID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var collaborator model.PostCollaborator
+	return collaborator.FindByUser(s.db, postID, inviteeID)
+}
+
+// AcceptInvitation makes the user an active collaborator on the post they were invited to.
+// Accepting twice is a no-op.
+func (s *CollaboratorService) AcceptInvitation(postID, userID uuid.UUID) (*model.PostCollaborator, error) {
+	var found model.PostCollaborator
+	collaborator, err := found.FindByUser(s.db, postID, userID)
+	if err != nil {
+		return nil, errors.New("invitation not found")
+	}
+
+	if collaborator.Status == model.CollaborationAccepted {
+		return collaborator, nil
+	}
+	if err := collaborator.Accept(s.db); err != nil {
+		return nil, err
+	}
+	return collaborator, nil
+}
+
+// GetInvitations lists the invitations the user has not answered yet.
+func (s *CollaboratorService) GetInvitations(userID uuid.UUID) ([]model.PostCollaborator, error) {
+	var collaborator model.PostCollaborator
+	return collaborator.FindPendingByUser(s.db, userID)
+}
+
+// RemoveCollaborator ends a collaboration or withdraws an invitation. Users can always
+// remove themselves, which declines an invitation; removing others takes the right to manage the post.
+func (s *CollaboratorService) RemoveCollaborator(postID, actorID, userID uuid.UUID) error {
+	var found model.PostCollaborator
+	collaborator, err := found.FindByUser(s.db, postID, userID)
+	if err != nil {
+		return errors.New("collaborator not found")
+	}
+
+	if actorID != userID {
+		var post model.Post
+		target, err := post.FindByID(s.db, postID)
+		if err != nil {
+			return errors.New("post not found")
+		}
+		if err := policy.AuthorizePost(s.db, target, actorID, policy.ManagePost); err != nil {
+			return err
+		}
+	}
+
+	return collaborator.Remove(s.db)
+}
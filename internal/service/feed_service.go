@@ -0,0 +1,170 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/feeds"
+	"gorm.io/gorm"
+)
+
+// feedSize is the number of most recent posts included in a feed.
+const feedSize = 20
+
+// Feed formats that can be served.
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatJSON = "json"
+)
+
+type FeedService struct {
+	db          *gorm.DB
+	postService *PostService
+	conf        *configs.Config
+}
+
+// NewFeedService creates a new feed service.
+func NewFeedService(db *gorm.DB, postService *PostService, conf *configs.Config) *FeedService {
+	return &FeedService{db: db, postService: postService, conf: conf}
+}
+
+// RenderedFeed is a serialized feed with the validators feed readers use for conditional requests.
+type RenderedFeed struct {
+	Body         string
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// SiteFeed renders the feed of the latest posts across the site.
+func (s *FeedService) SiteFeed(format string) (*RenderedFeed, error) {
+	feed := &feeds.Feed{
+		Title:       s.conf.SiteTitle,
+		Link:        &feeds.Link{Href: s.conf.AppURL + "/api/v1/posts"},
+		Description: "Latest posts on " + s.conf.SiteTitle,
+		Id:          s.conf.AppURL + "/api/v1/feeds/posts",
+	}
+	return s.render(feed, model.PostQuery{}, format)
+}
+
+// AuthorFeed renders the feed of the latest posts by a single author.
+func (s *FeedService) AuthorFeed(authorID uuid.UUID, format string) (*RenderedFeed, error) {
+	var user model.User
+	author, err := user.FindByID(s.db, authorID)
+	if err != nil {
+		return nil, errors.New("author not found")
+	}
+
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("%s - posts by %s", s.conf.SiteTitle, author.Name),
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/api/v1/posts?author=%s", s.conf.AppURL, author.ID)},
+		Description: fmt.Sprintf("Latest posts by %s on %s", author.Name, s.conf.SiteTitle),
+		Author:      &feeds.Author{Name: author.Name},
+		Id:          fmt.Sprintf("%s/api/v1/feeds/authors/%s", s.conf.AppURL, author.ID),
+	}
+	return s.render(feed, model.PostQuery{AuthorID: authorID}, format)
+}
+
+// TagFeed renders the feed of the latest posts with a tag.
+func (s *FeedService) TagFeed(tag, format string) (*RenderedFeed, error) {
+	tag = model.NormalizeTag(tag)
+	if tag == "" {
+		return nil, errors.New("invalid tag")
+	}
+
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("%s - posts tagged %s", s.conf.SiteTitle, tag),
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/api/v1/posts?tag=%s", s.conf.AppURL, tag)},
+		Description: fmt.Sprintf("Latest posts tagged %s on %s", tag, s.conf.SiteTitle),
+		Id:          fmt.Sprintf("%s/api/v1/feeds/tags/%s", s.conf.AppURL, tag),
+	}
+	return s.render(feed, model.PostQuery{Tag: tag}, format)
+}
+
+// render fills the feed with the posts matching the query and serializes it.
+// Posts come from the same query as the post listing, so feeds and listings never disagree.
+func (s *FeedService) render(feed *feeds.Feed, query model.PostQuery, format string) (*RenderedFeed, error) {
+	contentType, ok := feedContentTypes[format]
+	if !ok {
+		return nil, errors.New("invalid feed format")
+	}
+
+	query.Page = 1
+	query.Limit = feedSize
+	query.Sort = model.PostSortLatest
+	posts, _, err := s.postService.GetAllPosts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The validators cover the feed format and every post's ID and edit time,
+	// so any new, edited or removed post produces a different ETag
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", format)
+
+	var lastModified time.Time
+	for i := range posts {
+		post := &posts[i]
+		fmt.Fprintf(hash, "%s %d\n", post.ID, post.UpdatedAt.UnixNano())
+		if post.UpdatedAt.After(lastModified) {
+			lastModified = post.UpdatedAt
+		}
+
+		if err := post.ApplyFormat(model.PostFormatHTML); err != nil {
+			return nil, err
+		}
+		feed.Add(&feeds.Item{
+			Title:       post.Title,
+			Link:        &feeds.Link{Href: s.postURL(post)},
+			Author:      &feeds.Author{Name: post.User.Name},
+			Description: post.Excerpt,
+			Content:     post.Body,
+			Id:          "urn:uuid:" + post.ID.String(),
+			Created:     post.CreatedAt,
+			Updated:     post.UpdatedAt,
+		})
+	}
+	feed.Updated = lastModified
+
+	var body string
+	switch format {
+	case FeedFormatRSS:
+		body, err = feed.ToRss()
+	case FeedFormatAtom:
+		body, err = feed.ToAtom()
+	case FeedFormatJSON:
+		body, err = feed.ToJSON()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderedFeed{
+		Body:         body,
+		ContentType:  contentType,
+		ETag:         `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`,
+		LastModified: lastModified,
+	}, nil
+}
+
+// postURL is the public link to a post, preferring its slug.
+func (s *FeedService) postURL(post *model.Post) string {
+	if post.Slug != "" {
+		return s.conf.AppURL + "/api/v1/posts/by-slug/" + post.Slug
+	}
+	return s.conf.AppURL + "/api/v1/posts/" + post.ID.String()
+}
+
+// feedContentTypes maps each feed format to its media type.
+var feedContentTypes = map[string]string{
+	FeedFormatRSS:  "application/rss+xml; charset=utf-8",
+	FeedFormatAtom: "application/atom+xml; charset=utf-8",
+	FeedFormatJSON: "application/feed+json; charset=utf-8",
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// signatureVerifier is implemented by storage adapters whose signed links are served by the API.
+type signatureVerifier interface {
+	VerifySignature(key, expires, signature string) error
+}
+
+// uploadSignatureVerifier is implemented by storage adapters whose signed upload links are served by the API.
+type uploadSignatureVerifier interface {
+	VerifyUploadSignature(key, expires, signature string) error
+}
+
+type FileService struct {
+	db      *gorm.DB
+	storage storage.StorageAdapter
+}
+
+// NewFileService creates a new file service.
+func NewFileService(db *gorm.DB, storage storage.StorageAdapter) *FileService {
+	return &FileService{db: db, storage: storage}
+}
+
+// OpenSignedFile opens a stored file through a signed link. The caller must close it.
+// Only adapters that cannot sign links themselves, like the local one, send them here.
+func (s *FileService) OpenSignedFile(ctx context.Context, key, expires, signature string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	verifier, ok := s.storage.(signatureVerifier)
+	if !ok {
+		return nil, nil, errors.New("file not found")
+	}
+	if err := verifier.VerifySignature(key, expires, signature); err != nil {
+		return nil, nil, err
+	}
+
+	file, info, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) || errors.Is(err, storage.ErrInvalidObjectName) {
+		return nil, nil, errors.New("file not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// PutSignedFile stores content sent to a signed upload link, replacing anything sent before.
+// Only adapters that cannot sign links themselves, like the local one, send them here. Upload
+// links are only handed out with upload tickets, for the staging key of the ticket's file, so
+// content longer than the ticket declared is turned away with ErrUploadTooLarge.
+func (s *FileService) PutSignedFile(ctx context.Context, key, expires, signature string, content io.Reader, meta storage.ObjectMeta) error {
+	verifier, ok := s.storage.(uploadSignatureVerifier)
+	if !ok {
+		return errors.New("file not found")
+	}
+	if err := verifier.VerifyUploadSignature(key, expires, signature); err != nil {
+		return err
+	}
+
+	// Held keys are staged too, but must never be written to through a link
+	objectName, staged := storage.StagedKey(key)
+	if !staged || key != storage.StagingKey(objectName) {
+		return errors.New("upload ticket not found")
+	}
+	ticket, err := new(model.UploadTicket).FindByObjectName(s.db.WithContext(ctx), objectName)
+	if err != nil {
+		return errors.New("upload ticket not found")
+	}
+	if meta.Size > ticket.Size {
+		return ErrUploadTooLarge
+	}
+	return s.storage.Put(ctx, key, &sizeLimitReader{r: content, n: ticket.Size}, meta)
+}
+
+// sizeLimitReader fails with ErrUploadTooLarge once more than n bytes are read.
+type sizeLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FollowService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+// NewFollowService creates a new follow service.
+func NewFollowService(db *gorm.DB, notifications *NotificationService) *FollowService {
+	return &FollowService{db: db, notifications: notifications}
+}
+
+// Follow makes the follower see the followee's followers-only posts.
+// The followee is notified the first time they are followed by this user.
+func (s *FollowService) Follow(followerID, followeeID uuid.UUID) error {
+	if followerID == followeeID {
+		return errors.New("you cannot follow yourself")
+	}
+
+	var user model.User
+	if _, err := user.FindByID(s.db, followeeID); err != nil {
+		return err // User not found
+	}
+
+	return s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		follow := model.Follow{FollowerID: followerID, FolloweeID: followeeID}
+		if err := follow.Add(tx); err != nil {
+			return err
+		}
+
+		dedupeKey := fmt.Sprintf("%s:%s:%s", model.NotificationFollow, followerID, followeeID)
+		return batch.Notify(&model.Notification{
+			UserID:     followeeID,
+			ActorID:    &followerID,
+			Type:       model.NotificationFollow,
+			TargetType: model.NotificationTargetUser,
+			TargetID:   followerID,
+			DedupeKey:  &dedupeKey,
+		})
+	})
+}
+
+// Unfollow removes the follow relation, if any.
+func (s *FollowService) Unfollow(followerID, followeeID uuid.UUID) error {
+	follow := model.Follow{FollowerID: followerID, FolloweeID: followeeID}
+	return follow.Remove(s.db)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"venturo-core/configs"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ModerationService struct {
+	db   *gorm.DB
+	conf *configs.Config
+}
+
+// NewModerationService creates a new moderation service.
+func NewModerationService(db *gorm.DB, conf *configs.Config) *ModerationService {
+	return &ModerationService{db: db, conf: conf}
+}
+
+// ReportPost files a user's report against a post. Once the post gathers the configured
+// number of open reports, it is hidden until a moderator reviews it.
+func (s *ModerationService) ReportPost(postID, reporterID uuid.UUID, reason, note string) (*model.PostReport, error) {
+	var found model.Post
+	post, err := found.FindByID(s.db.Scopes(model.ReadableBy(reporterID)), postID)
+	if err != nil {
+		return nil, errors.New("post not found")
+	}
+	if post.UserID == reporterID {
+		return nil, errors.New("you cannot report your own post")
+	}
+
+	report := model.PostReport{PostID: postID, ReporterID: reporterID, Reason: reason, Note: note}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the post so that concurrent reports cannot both trigger the automatic hide
+		locked, err := new(model.Post).FindByIDForUpdate(tx, postID)
+		if err != nil {
+			return err
+		}
+
+		created, err := report.Create(tx)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return errors.New("you have already reported this post")
+		}
+
+		threshold := s.conf.ReportAutoHideThreshold
+		if threshold <= 0 || locked.HiddenAt != nil {
+			return nil
+		}
+		open, err := report.CountOpenByPostID(tx, postID)
+		if err != nil || open < int64(threshold) {
+			return err
+		}
+
+		if err := locked.Hide(tx); err != nil {
+			return err
+		}
+		action := model.ModerationAction{
+			PostID: postID,
+			Action: model.ModerationHide,
+			Reason: fmt.Sprintf("automatically hidden after %d reports", open),
+		}
+		return action.Create(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetReportQueue lists the posts with open reports, grouped by post. Only moderators can see it.
+func (s *ModerationService) GetReportQueue(moderatorID uuid.UUID, page, limit int) ([]model.ReportedPost, int64, error) {
+	if err := s.requireModerator(moderatorID); err != nil {
+		return nil, 0, err
+	}
+
+	var report model.PostReport
+	entries, total, err := report.FindQueue(s.db, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].PostID
+	}
+
+	reasons, err := report.CountOpenReasons(s.db, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Moderators need to see reported posts even after their author trashed them
+	var post model.Post
+	posts, err := post.FindAllByIDs(s.db.Unscoped(), ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]*model.Post, len(posts))
+	for i := range posts {
+		byID[posts[i].ID] = &posts[i]
+	}
+
+	for i := range entries {
+		entries[i].Reasons = reasons[entries[i].PostID]
+		entries[i].Post = byID[entries[i].PostID]
+	}
+	return entries, total, nil
+}
+
+// TakeAction applies a moderator's decision to a post, resolves its open reports
+// and records the decision with the moderator and reason.
+func (s *ModerationService) TakeAction(postID, moderatorID uuid.UUID, action, reason string) (*model.ModerationAction, error) {
+	if err := s.requireModerator(moderatorID); err != nil {
+		return nil, err
+	}
+
+	record := model.ModerationAction{PostID: postID, ModeratorID: &moderatorID, Action: action, Reason: reason}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		post, err := new(model.Post).FindByIDForUpdate(tx.Unscoped(), postID)
+		if err != nil {
+			return err // Post not found
+		}
+
+		switch action {
+		case model.ModerationDismiss:
+			err = post.Unhide(tx)
+		case model.ModerationHide:
+			err = post.Hide(tx)
+		case model.ModerationDelete:
+			if err = post.Hide(tx); err == nil && !post.DeletedAt.Valid {
+				err = post.Delete(tx)
+			}
+		case model.ModerationSuspendAuthor:
+			if err = post.Hide(tx); err == nil {
+				err = suspendAuthor(tx, post.UserID)
+			}
+		default:
+			return errors.New("invalid moderation action")
+		}
+		if err != nil {
+			return err
+		}
+
+		var report model.PostReport
+		if err := report.ResolveOpenByPostID(tx, postID); err != nil {
+			return err
+		}
+		return record.Create(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// requireModerator ensures the user holds a moderation role.
+func (s *ModerationService) requireModerator(userID uuid.UUID) error {
+	var found model.User
+	user, err := found.FindByID(s.db, userID)
+	if err != nil {
+		return err
+	}
+	return policy.RequireModerator(user)
+}
+
+// suspendAuthor suspends the author of a post. Moderators cannot be suspended this way.
+func suspendAuthor(tx *gorm.DB, authorID uuid.UUID) error {
+	var found model.User
+	author, err := found.FindByID(tx, authorID)
+	if err != nil {
+		return err
+	}
+	if author.IsModerator() {
+		return errors.New("cannot suspend a moderator")
+	}
+	if author.IsSuspended() {
+		return nil
+	}
+	return author.Suspend(tx)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/pubsub"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationService struct {
+	db  *gorm.DB
+	hub *pubsub.Hub[uuid.UUID, model.Notification]
+}
+
+// NewNotificationService creates a new notification service.
+// Live notification streams are ended once ctx is cancelled, so they do not hold up shutdown.
+func NewNotificationService(ctx context.Context, db *gorm.DB) *NotificationService {
+	s := &NotificationService{db: db, hub: pubsub.NewHub[uuid.UUID, model.Notification]()}
+	go func() {
+		<-ctx.Done()
+		s.hub.Close()
+	}()
+	return s
+}
+
+// notificationBatch collects the notifications created within one transaction,
+// so they are only pushed to live streams once the transaction has committed.
+type notificationBatch struct {
+	tx      *gorm.DB
+	created []uuid.UUID
+}
+
+// Notify stores the notification unless the recipient turned its type off
+// or the same event was already notified.
+func (b *notificationBatch) Notify(notification *model.Notification) error {
+	var preference model.NotificationPreference
+	enabled, err := preference.IsEnabled(b.tx, notification.UserID, notification.Type)
+	if err != nil || !enabled {
+		return err
+	}
+
+	created, err := notification.Create(b.tx)
+	if err != nil || !created {
+		return err
+	}
+	b.created = append(b.created, notification.ID)
+	return nil
+}
+
+// Transaction runs fn in a database transaction. Notifications sent through the batch
+// are delivered to the recipients' live streams after the transaction commits.
+func (s *NotificationService) Transaction(fn func(tx *gorm.DB, batch *notificationBatch) error) error {
+	var batch *notificationBatch
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		batch = &notificationBatch{tx: tx}
+		return fn(tx, batch)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(batch.created)
+	return nil
+}
+
+// publish pushes freshly committed notifications to their recipients' live streams.
+// Delivery is best effort: the notification is stored either way.
+func (s *NotificationService) publish(ids []uuid.UUID) {
+	if len(ids) == 0 {
+		return
+	}
+
+	var notifications []model.Notification
+	if err := s.db.Preload("Actor").Where("id IN ?", ids).Find(&notifications).Error; err != nil {
+		slog.Error("Failed to load notifications for delivery", "error", err)
+		return
+	}
+	for _, notification := range notifications {
+		s.hub.Publish(notification.UserID, notification)
+	}
+}
+
+// Subscribe starts a live stream of the user's new notifications.
+// The caller must call the returned function once it stops reading.
+func (s *NotificationService) Subscribe(userID uuid.UUID) (<-chan model.Notification, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// GetNotifications retrieves a page of the user's notifications along with their unread count.
+func (s *NotificationService) GetNotifications(userID uuid.UUID, unreadOnly bool, page, limit int) ([]model.Notification, int64, int64, error) {
+	var notification model.Notification
+	notifications, total, err := notification.FindAllByUser(s.db, userID, unreadOnly, page, limit)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	unread, err := s.CountUnread(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return notifications, total, unread, nil
+}
+
+// CountUnread counts the user's unread notifications.
+func (s *NotificationService) CountUnread(userID uuid.UUID) (int64, error) {
+	var notification model.Notification
+	return notification.CountUnread(s.db, userID)
+}
+
+// MarkRead marks one of the user's notifications as read.
+func (s *NotificationService) MarkRead(userID, id uuid.UUID) (*model.Notification, error) {
+	var found model.Notification
+	notification, err := found.FindByID(s.db, userID, id)
+	if err != nil {
+		return nil, errors.New("notification not found")
+	}
+
+	if err := notification.MarkRead(s.db); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks all of the user's notifications as read and returns how many were unread.
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) (int64, error) {
+	var notification model.Notification
+	return notification.MarkAllRead(s.db, userID)
+}
+
+// GetPreferences retrieves which notification types the user receives.
+func (s *NotificationService) GetPreferences(userID uuid.UUID) (map[string]bool, error) {
+	var preference model.NotificationPreference
+	return preference.FindAllByUser(s.db, userID)
+}
+
+// UpdatePreferences turns notification types on or off for the user.
+// Types left out of changes keep their current setting.
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, changes map[string]bool) (map[string]bool, error) {
+	for notificationType := range changes {
+		if !slices.Contains(model.NotificationTypes, notificationType) {
+			return nil, errors.New("invalid notification type: " + notificationType)
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for notificationType, enabled := range changes {
+			preference := model.NotificationPreference{UserID: userID, Type: notificationType, Enabled: enabled}
+			if err := preference.Save(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPreferences(userID)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// localUploadPaths are the directories files are kept in on the server until they are uploaded,
+// including the legacy ones under ./public, in case the upload queue could not move a file out.
+// Quarantined files are kept for inspection, so their directory is left alone.
+var localUploadPaths = append(
+	[]string{tempUploadPath, attachmentUploadPath, storedFileUploadPath, resumableUploadPath},
+	slices.Collect(maps.Keys(legacyUploadPaths))...,
+)
+
+// orphanLocationStorage marks orphans found in storage rather than on the server.
+const orphanLocationStorage = "storage"
+
+// OrphanFile is a file nothing in the database refers to.
+type OrphanFile struct {
+	// Location is "storage", or the server directory the file was found in
+	Location string    `json:"location"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// OrphanReport describes what a collection found and, unless it was a dry run, deleted.
+type OrphanReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Scanned int          `json:"scanned"`
+	Orphans []OrphanFile `json:"orphans"`
+	Bytes   int64        `json:"bytes"`
+	Deleted int          `json:"deleted"`
+}
+
+// OrphanCollector deletes files left behind by uploads: objects in storage no record refers
+// to, such as replaced avatars, and files on the server whose upload gave up. Only files older
+// than the grace period are touched, so uploads under way are never mistaken for orphans.
+type OrphanCollector struct {
+	db      *gorm.DB
+	storage storage.StorageAdapter
+	grace   time.Duration
+	dryRun  bool
+}
+
+// NewOrphanCollector creates a new orphan collector.
+func NewOrphanCollector(db *gorm.DB, conf *configs.Config, storageAdapter storage.StorageAdapter) *OrphanCollector {
+	return &OrphanCollector{
+		db:      db,
+		storage: storageAdapter,
+		grace:   conf.OrphanGracePeriod,
+		dryRun:  conf.OrphanDryRun,
+	}
+}
+
+// Run collects orphans in the background, only reporting them when ORPHAN_GC_DRY_RUN is set.
+func (c *OrphanCollector) Run(ctx context.Context) {
+	if _, err := c.Collect(ctx, c.grace, c.dryRun); err != nil {
+		slog.Error("Failed to collect orphaned files", "error", err)
+	}
+}
+
+// Collect finds files older than grace that nothing refers to and deletes them, or only
+// reports them on a dry run. Every orphan is logged.
+func (c *OrphanCollector) Collect(ctx context.Context, grace time.Duration, dryRun bool) (*OrphanReport, error) {
+	report := &OrphanReport{DryRun: dryRun, Orphans: []OrphanFile{}}
+	cutoff := time.Now().Add(-grace)
+
+	// References are read before listing, so a file referenced in between is too new to collect
+	refs, err := model.FindReferencedObjects(c.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := model.FindTicketObjects(c.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.storage.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		report.Scanned++
+		// Files uploaded through a ticket are staged under a key derived from the ticket's object name
+		target, staged := storage.StagedKey(object.Key)
+		referenced := refs[target]
+		if staged && object.Key == storage.StagingKey(target) {
+			// Upload links outlive their ticket, so only an open ticket keeps what was sent to one
+			referenced = tickets[target]
+		}
+		if referenced || object.LastModified.After(cutoff) {
+			continue
+		}
+		c.collect(report, OrphanFile{
+			Location: orphanLocationStorage,
+			Name:     object.Key,
+			Size:     object.Size,
+			ModTime:  object.LastModified,
+		}, func() error {
+			return c.storage.Delete(ctx, object.Key)
+		})
+	}
+
+	pending, err := model.FindPendingLocalObjects(c.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range localUploadPaths {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // Uploaded while listing
+			}
+			if err != nil {
+				return nil, err
+			}
+			report.Scanned++
+			if pending[entry.Name()] || info.ModTime().After(cutoff) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			c.collect(report, OrphanFile{
+				Location: dir,
+				Name:     entry.Name(),
+				Size:     info.Size(),
+				ModTime:  info.ModTime(),
+			}, func() error {
+				err := os.Remove(path)
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			})
+		}
+	}
+
+	if len(report.Orphans) > 0 {
+		slog.Info("Collected orphaned files", "dryRun", dryRun, "scanned", report.Scanned,
+			"orphans", len(report.Orphans), "bytes", report.Bytes, "deleted", report.Deleted)
+	}
+	return report, nil
+}
+
+// collect records an orphan in the report and deletes it unless the collection is a dry run.
+func (c *OrphanCollector) collect(report *OrphanReport, orphan OrphanFile, remove func() error) {
+	report.Orphans = append(report.Orphans, orphan)
+	report.Bytes += orphan.Size
+	slog.Info("Orphaned file", "location", orphan.Location, "file", orphan.Name,
+		"size", orphan.Size, "modified", orphan.ModTime, "dryRun", report.DryRun)
+	if report.DryRun {
+		return
+	}
+	if err := remove(); err != nil {
+		slog.Error("Error deleting orphaned file", "location", orphan.Location, "file", orphan.Name, "error", err)
+		return
+	}
+	report.Deleted++
+}
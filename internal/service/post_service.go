@@ -1,86 +1,536 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"venturo-core/configs"
 	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+	"venturo-core/pkg/mention"
+	"venturo-core/pkg/textdiff"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// maxMentionsPerPost caps how many users a single post can mention and notify.
+const maxMentionsPerPost = 20
+
+// slugBackfillBatchSize caps the posts BackfillSlugs gives a slug in one transaction.
+const slugBackfillBatchSize = 100
+
 type PostService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	conf          *configs.Config
+	attachments   *AttachmentService
+	notifications *NotificationService
 }
 
 // NewPostService creates a new post service.
-func NewPostService(db *gorm.DB) *PostService {
-	return &PostService{db: db}
+func NewPostService(db *gorm.DB, conf *configs.Config, attachments *AttachmentService, notifications *NotificationService) *PostService {
+	return &PostService{db: db, conf: conf, attachments: attachments, notifications: notifications}
 }
 
-// CreatePost creates a new post for a given user.
-func (s *PostService) CreatePost(userID uuid.UUID, title, body string) (*model.Post, error) {
+// RevisionDiff describes the changes between two revisions of a post.
+type RevisionDiff struct {
+	PostID uuid.UUID       `json:"post_id"`
+	From   int             `json:"from"`
+	To     int             `json:"to"`
+	Title  []textdiff.Line `json:"title"`
+	Body   []textdiff.Line `json:"body"`
+}
+
+// CreatePost creates a new post for a given user. An empty visibility makes the post public.
+func (s *PostService) CreatePost(userID uuid.UUID, title, body, visibility string, tags []string) (*model.Post, error) {
+	if visibility == "" {
+		visibility = model.VisibilityPublic
+	}
+
 	post := model.Post{
-		Title:  title,
-		Body:   body,
-		UserID: userID,
+		Title:      title,
+		Body:       body,
+		Visibility: visibility,
+		UserID:     userID,
+		Tags:       model.NormalizeTags(tags),
 	}
 
-	if err := post.Save(s.db); err != nil {
+	err := s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		if err := post.Save(tx); err != nil {
+			return err
+		}
+		if err := assignSlug(tx, &post); err != nil {
+			return err
+		}
+		var postTag model.PostTag
+		if err := postTag.ReplaceForPost(tx, post.ID, post.Tags); err != nil {
+			return err
+		}
+		if err := syncMentions(tx, batch, &post, userID); err != nil {
+			return err
+		}
+		// The initial content is the first revision
+		return recordRevision(tx, &post, userID, 1)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-// GetAllPosts retrieves all posts.
-func (s *PostService) GetAllPosts(page, limit int) ([]model.Post, int64, error) {
+// GetAllPosts retrieves a page of posts matching the query.
+// For authenticated viewers each post tells whether the viewer bookmarked it.
+func (s *PostService) GetAllPosts(query model.PostQuery) ([]model.Post, int64, error) {
 	var post model.Post
-	return post.FindAll(s.db, page, limit)
+	posts, total, err := post.FindAll(s.db, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if query.ViewerID != uuid.Nil {
+		refs := make([]*model.Post, len(posts))
+		for i := range posts {
+			refs[i] = &posts[i]
+		}
+		if err := markBookmarked(s.db, query.ViewerID, refs); err != nil {
+			return nil, 0, err
+		}
+	}
+	return posts, total, nil
+}
+
+// GetPostByID retrieves a single post by its ID, along with its attachments, reaction counts
+// and its place in a series. Posts the viewer may not read are reported as not found.
+// When viewerID is not uuid.Nil, the viewer's own reactions and bookmark are included as well.
+func (s *PostService) GetPostByID(id, viewerID uuid.UUID) (*model.Post, error) {
+	var found model.Post
+	post, err := found.FindByID(s.db.Scopes(model.ReadableBy(viewerID)), id)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachment model.PostAttachment
+	if post.Attachments, err = attachment.FindAllByPostID(s.db, id); err != nil {
+		return nil, err
+	}
+
+	var postTag model.PostTag
+	tags, err := postTag.FindByPostIDs(s.db, []uuid.UUID{id})
+	if err != nil {
+		return nil, err
+	}
+	post.Tags = tags[id]
+
+	var counter model.PostReactionCount
+	if post.Reactions, err = counter.FindByPostID(s.db, id); err != nil {
+		return nil, err
+	}
+
+	if post.Series, err = seriesNavigation(s.db, viewerID, id); err != nil {
+		return nil, err
+	}
+
+	if viewerID != uuid.Nil {
+		var reaction model.PostReaction
+		if post.MyReactions, err = reaction.FindTypesByUser(s.db, id, viewerID); err != nil {
+			return nil, err
+		}
+		if err := markBookmarked(s.db, viewerID, []*model.Post{post}); err != nil {
+			return nil, err
+		}
+	}
+
+	return post, nil
 }
 
-// GetPostByID retrieves a single post by its ID.
-func (s *PostService) GetPostByID(id uuid.UUID) (*model.Post, error) {
+// GetPostBySlug retrieves a post by its current slug. When the slug has been retired
+// by a title change, no post is returned; instead the post's current slug is given so the caller can redirect.
+func (s *PostService) GetPostBySlug(slug string, viewerID uuid.UUID) (*model.Post, string, error) {
+	var postSlug model.PostSlug
+	record, err := postSlug.FindBySlug(s.db, slug)
+	if err != nil {
+		return nil, "", err // Slug not found
+	}
+
+	post, err := s.GetPostByID(record.PostID, viewerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if post.Slug != slug {
+		return nil, post.Slug, nil
+	}
+	return post, "", nil
+}
+
+// findPost loads a post with its author, without any per-viewer data.
+func (s *PostService) findPost(id uuid.UUID) (*model.Post, error) {
 	var post model.Post
 	return post.FindByID(s.db, id)
 }
 
-// DeletePost finds a post, checks for ownership, and deletes it.
+// DeletePost finds a post, checks that the user may manage it, and moves it to the trash.
 func (s *PostService) DeletePost(postID, userID uuid.UUID) error {
 	// Find the post first
-	post, err := s.GetPostByID(postID)
+	post, err := s.findPost(postID)
 	if err != nil {
 		return err // Post not found
 	}
 
-	// Authorization Check: Ensure the user owns the post
-	if post.UserID != userID {
-		return errors.New("unauthorized: you are not the owner of this post")
+	if err := policy.AuthorizePost(s.db, post, userID, policy.ManagePost); err != nil {
+		return err
 	}
 
-	// Delete the post
+	// Move the post to the trash
 	return post.Delete(s.db)
 }
 
-// UpdatePost finds a post, checks for ownership, and updates it.
-func (s *PostService) UpdatePost(postID, userID uuid.UUID, newTitle, newBody string) (*model.Post, error) {
-	// Find the post first
-	post, err := s.GetPostByID(postID)
+// GetTrash lists the posts a user has moved to the trash.
+func (s *PostService) GetTrash(userID uuid.UUID, page, limit int) ([]model.Post, int64, error) {
+	var post model.Post
+	return post.FindTrashedByUser(s.db, userID, page, limit)
+}
+
+// RestorePost takes a post the user may manage out of the trash.
+func (s *PostService) RestorePost(postID, userID uuid.UUID) (*model.Post, error) {
+	var post model.Post
+	trashed, err := post.FindTrashedByID(s.db, postID)
+	if err != nil {
+		return nil, err // Post not found in the trash
+	}
+
+	if err := policy.AuthorizePost(s.db, trashed, userID, policy.ManagePost); err != nil {
+		return nil, err
+	}
+
+	if err := trashed.Restore(s.db); err != nil {
+		return nil, err
+	}
+	return trashed, nil
+}
+
+// PurgeTrash permanently deletes posts that have been in the trash longer than the retention period,
+// along with the stored files of their attachments. Trashed posts keep their files so they can be restored.
+// A retention period of zero or less never purges anything.
+func (s *PostService) PurgeTrash(ctx context.Context) {
+	if s.conf.PostTrashRetention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-s.conf.PostTrashRetention)
+
+	var purged int64
+	var attachments []model.PostAttachment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post model.Post
+		ids, err := post.FindTrashedBeforeForUpdate(tx, cutoff)
+		if err != nil {
+			return err
+		}
+
+		var attachment model.PostAttachment
+		if attachments, err = attachment.FindAllByPostIDs(tx, ids); err != nil {
+			return err
+		}
+
+		purged, err = post.PurgeByIDs(tx, ids)
+		return err
+	})
+	if err != nil {
+		slog.Error("Error purging trashed posts", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.Info("Purged trashed posts", "count", purged, "cutoff", cutoff)
+	}
+
+	s.attachments.DeleteFiles(attachments)
+}
+
+// UpdatePost finds a post, checks that the user may edit it, and updates it.
+// Every update that changes the content is recorded as a new revision.
+// An empty visibility leaves the current visibility unchanged; changing it takes the right to manage the post.
+// Nil tags leave the current tags unchanged, while an empty list removes them all.
+func (s *PostService) UpdatePost(postID, userID uuid.UUID, newTitle, newBody, newVisibility string, newTags []string) (*model.Post, error) {
+	err := s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		// Lock the post so concurrent edits get consecutive revision numbers
+		post, err := new(model.Post).FindByIDForUpdate(tx, postID)
+		if err != nil {
+			return err // Post not found
+		}
+
+		if err := policy.AuthorizePost(tx, post, userID, policy.EditPost); err != nil {
+			return err
+		}
+
+		visibilityChanged := newVisibility != "" && newVisibility != post.Visibility
+		if visibilityChanged {
+			if err := policy.AuthorizePost(tx, post, userID, policy.ManagePost); err != nil {
+				return err
+			}
+			if err := post.UpdateVisibility(tx, newVisibility); err != nil {
+				return err
+			}
+		}
+
+		if err := applyEdit(tx, batch, post, userID, newTitle, newBody); err != nil {
+			return err
+		}
+		if newTags != nil {
+			var postTag model.PostTag
+			if err := postTag.ReplaceForPost(tx, post.ID, model.NormalizeTags(newTags)); err != nil {
+				return err
+			}
+		}
+
+		// Mentioned users who could not read the post before may be able to now
+		if visibilityChanged {
+			return notifyMentions(tx, batch, post, userID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPostByID(postID, userID)
+}
+
+// GetRevisions lists the revision history of a post. Only users who may edit the post can see it.
+func (s *PostService) GetRevisions(postID, userID uuid.UUID) ([]model.PostRevision, error) {
+	if _, err := s.findEditablePost(postID, userID); err != nil {
+		return nil, err
+	}
+
+	var revision model.PostRevision
+	return revision.FindAllByPostID(s.db, postID)
+}
+
+// DiffRevisions compares two revisions of a post line by line.
+func (s *PostService) DiffRevisions(postID, userID uuid.UUID, from, to int) (*RevisionDiff, error) {
+	if _, err := s.findEditablePost(postID, userID); err != nil {
+		return nil, err
+	}
+
+	var revision model.PostRevision
+	fromRev, err := revision.FindByRevision(s.db, postID, from)
+	if err != nil {
+		return nil, errors.New("revision not found")
+	}
+	toRev, err := revision.FindByRevision(s.db, postID, to)
+	if err != nil {
+		return nil, errors.New("revision not found")
+	}
+
+	return &RevisionDiff{
+		PostID: postID,
+		From:   from,
+		To:     to,
+		Title:  textdiff.Lines(fromRev.Title, toRev.Title),
+		Body:   textdiff.Lines(fromRev.Body, toRev.Body),
+	}, nil
+}
+
+// RestoreRevision copies the content of an old revision back onto the post.
+// The restore itself is recorded as a new revision, so history is never rewritten.
+func (s *PostService) RestoreRevision(postID, userID uuid.UUID, revisionNumber int) (*model.Post, error) {
+	err := s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		post, err := new(model.Post).FindByIDForUpdate(tx, postID)
+		if err != nil {
+			return err // Post not found
+		}
+
+		if err := policy.AuthorizePost(tx, post, userID, policy.EditPost); err != nil {
+			return err
+		}
+
+		var revision model.PostRevision
+		rev, err := revision.FindByRevision(tx, postID, revisionNumber)
+		if err != nil {
+			return errors.New("revision not found")
+		}
+
+		return applyEdit(tx, batch, post, userID, rev.Title, rev.Body)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetPostByID(postID, userID)
+}
+
+// findEditablePost loads a post and ensures the given user may edit it.
+func (s *PostService) findEditablePost(postID, userID uuid.UUID) (*model.Post, error) {
+	post, err := s.findPost(postID)
 	if err != nil {
 		return nil, err // Post not found
 	}
 
-	// Authorization Check: Ensure the user owns the post
-	if post.UserID != userID {
-		return nil, errors.New("unauthorized: you are not the owner of this post")
+	if err := policy.AuthorizePost(s.db, post, userID, policy.EditPost); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// applyEdit writes new content to a locked post and records it as the next revision.
+// It must run inside the transaction that locked the post.
+func applyEdit(tx *gorm.DB, batch *notificationBatch, post *model.Post, editorID uuid.UUID, newTitle, newBody string) error {
+	if post.Title == newTitle && post.Body == newBody {
+		return nil // Nothing changed, so there is nothing to record
+	}
+
+	var revision model.PostRevision
+	latest, err := revision.LatestRevisionNumber(tx, post.ID)
+	if err != nil {
+		return err
+	}
+
+	// Posts written before revisions existed get their current content saved first
+	if latest == 0 {
+		if err := recordRevision(tx, post, post.UserID, 1); err != nil {
+			return err
+		}
+		latest = 1
 	}
 
-	// Update the fields
 	post.Title = newTitle
 	post.Body = newBody
+	if err := post.Save(tx); err != nil {
+		return err
+	}
+	if err := assignSlug(tx, post); err != nil {
+		return err
+	}
+	if err := syncMentions(tx, batch, post, editorID); err != nil {
+		return err
+	}
 
-	// Save the updated post
-	if err := post.Save(s.db); err != nil {
-		return nil, err
+	return recordRevision(tx, post, editorID, latest+1)
+}
+
+// assignSlug gives the post a unique slug derived from its title. The current slug is kept
+// while the title still maps to the base it was claimed for; otherwise a new one is claimed and
+// the old one stays behind as a redirect.
+func assignSlug(tx *gorm.DB, post *model.Post) error {
+	base := model.MakeSlug(post.Title)
+
+	var postSlug model.PostSlug
+	if post.Slug != "" {
+		current, err := postSlug.FindBySlug(tx, post.Slug)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && current.Base == base {
+			return nil
+		}
 	}
 
-	return post, nil
+	slug, err := postSlug.Claim(tx, post.ID, base)
+	if err != nil {
+		return err
+	}
+	return post.UpdateSlug(tx, slug)
+}
+
+// BackfillSlugs gives a slug to every post created before posts had them, trashed ones included,
+// and returns how many it gave one. It is run once, through the migration tool.
+func BackfillSlugs(ctx context.Context, db *gorm.DB) (int, error) {
+	total := 0
+	for {
+		var count int
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var found model.Post
+			posts, err := found.FindWithoutSlugForUpdate(tx, slugBackfillBatchSize)
+			if err != nil {
+				return err
+			}
+			for i := range posts {
+				if err := assignSlug(tx.Unscoped(), &posts[i]); err != nil {
+					return err
+				}
+			}
+			count = len(posts)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += count
+		if count < slugBackfillBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// recordRevision stores the post's current content as the given revision number.
+func recordRevision(tx *gorm.DB, post *model.Post, editorID uuid.UUID, number int) error {
+	revision := model.PostRevision{
+		PostID:   post.ID,
+		Revision: number,
+		Title:    post.Title,
+		Body:     post.Body,
+		EditorID: editorID,
+	}
+	return revision.Create(tx)
+}
+
+// syncMentions stores the users mentioned in the post's body and notifies them.
+// Authors mentioning themselves are ignored.
+func syncMentions(tx *gorm.DB, batch *notificationBatch, post *model.Post, actorID uuid.UUID) error {
+	var user model.User
+	mentioned, err := user.FindAllByUsernames(tx, mention.Extract(post.Body, maxMentionsPerPost))
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(mentioned))
+	for _, u := range mentioned {
+		if u.ID != post.UserID {
+			userIDs = append(userIDs, u.ID)
+		}
+	}
+
+	var postMention model.PostMention
+	if err := postMention.ReplaceForPost(tx, post.ID, userIDs); err != nil {
+		return err
+	}
+	return notifyMentions(tx, batch, post, actorID)
+}
+
+// notifyMentions notifies the users mentioned in the post who can read it. Each user is
+// notified at most once per post, so edits only alert users who were not notified before.
+func notifyMentions(tx *gorm.DB, batch *notificationBatch, post *model.Post, actorID uuid.UUID) error {
+	var postMention model.PostMention
+	userIDs, err := postMention.FindUserIDsByPostID(tx, post.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if userID == actorID {
+			continue
+		}
+		readable, err := post.IsReadableBy(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !readable {
+			continue
+		}
+
+		dedupeKey := fmt.Sprintf("%s:%s:%s", model.NotificationMention, post.ID, userID)
+		notification := model.Notification{
+			UserID:     userID,
+			ActorID:    &actorID,
+			Type:       model.NotificationMention,
+			TargetType: model.NotificationTargetPost,
+			TargetID:   post.ID,
+			DedupeKey:  &dedupeKey,
+		}
+		if err := batch.Notify(&notification); err != nil {
+			return err
+		}
+	}
+	return nil
 }
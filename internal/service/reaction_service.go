@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"venturo-core/configs"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReactionService struct {
+	db            *gorm.DB
+	conf          *configs.Config
+	notifications *NotificationService
+}
+
+// NewReactionService creates a new reaction service.
+func NewReactionService(db *gorm.DB, conf *configs.Config, notifications *NotificationService) *ReactionService {
+	return &ReactionService{db: db, conf: conf, notifications: notifications}
+}
+
+// ReactionSummary is the reaction state of a post as seen by one user.
+type ReactionSummary struct {
+	PostID      uuid.UUID        `json:"post_id"`
+	Reactions   map[string]int64 `json:"reactions"`
+	MyReactions []string         `json:"my_reactions"`
+}
+
+// React adds the user's reaction of the given type to a post. Reacting twice is a no-op.
+// The post's author is notified of the first reaction each user leaves on the post.
+func (s *ReactionService) React(postID, userID uuid.UUID, reactionType string) (*ReactionSummary, error) {
+	return s.change(postID, userID, reactionType, func(tx *gorm.DB, reaction *model.PostReaction) (int, error) {
+		added, err := reaction.Add(tx)
+		if err != nil || !added {
+			return 0, err
+		}
+		return 1, nil
+	})
+}
+
+// Unreact removes the user's reaction of the given type from a post. Removing a missing reaction is a no-op.
+func (s *ReactionService) Unreact(postID, userID uuid.UUID, reactionType string) (*ReactionSummary, error) {
+	return s.change(postID, userID, reactionType, func(tx *gorm.DB, reaction *model.PostReaction) (int, error) {
+		removed, err := reaction.Remove(tx)
+		if err != nil || !removed {
+			return 0, err
+		}
+		return -1, nil
+	})
+}
+
+// change validates the request and applies a reaction change and its counter update in one transaction.
+func (s *ReactionService) change(postID, userID uuid.UUID, reactionType string, apply func(*gorm.DB, *model.PostReaction) (int, error)) (*ReactionSummary, error) {
+	if !slices.Contains(s.conf.PostReactionTypes, reactionType) {
+		return nil, errors.New("invalid reaction type")
+	}
+
+	// Users can only react to posts they are allowed to read
+	var found model.Post
+	post, err := found.FindByID(s.db.Scopes(model.ReadableBy(userID)), postID)
+	if err != nil {
+		return nil, err // Post not found
+	}
+
+	err = s.notifications.Transaction(func(tx *gorm.DB, batch *notificationBatch) error {
+		reaction := model.PostReaction{PostID: postID, UserID: userID, Type: reactionType}
+		delta, err := apply(tx, &reaction)
+		if err != nil || delta == 0 {
+			return err
+		}
+
+		var counter model.PostReactionCount
+		if err := counter.AdjustCount(tx, postID, reactionType, delta); err != nil {
+			return err
+		}
+
+		if delta < 0 || post.UserID == userID {
+			return nil
+		}
+		dedupeKey := fmt.Sprintf("%s:%s:%s", model.NotificationReaction, postID, userID)
+		return batch.Notify(&model.Notification{
+			UserID:     post.UserID,
+			ActorID:    &userID,
+			Type:       model.NotificationReaction,
+			TargetType: model.NotificationTargetPost,
+			TargetID:   postID,
+			DedupeKey:  &dedupeKey,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.summary(postID, userID)
+}
+
+// summary loads the aggregated counts of a post and the user's own reactions.
+func (s *ReactionService) summary(postID, userID uuid.UUID) (*ReactionSummary, error) {
+	var counter model.PostReactionCount
+	counts, err := counter.FindByPostID(s.db, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reaction model.PostReaction
+	mine, err := reaction.FindTypesByUser(s.db, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mine == nil {
+		mine = []string{}
+	}
+
+	return &ReactionSummary{PostID: postID, Reactions: counts, MyReactions: mine}, nil
+}
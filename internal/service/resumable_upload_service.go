@@ -0,0 +1,412 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/uploader"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// resumableUploadPath holds the files of resumable uploads while their chunks arrive. Like the
+// other temp directories it is not served, since it holds unscanned partial files of any type.
+const resumableUploadPath = "./uploads/resumable"
+
+// maxUploadMetadataLength matches the size of the metadata column.
+const maxUploadMetadataLength = 1000
+
+// expiredUploadBatchSize caps the expired uploads a single purge removes.
+const expiredUploadBatchSize = 100
+
+var (
+	// ErrUploadOffsetMismatch is returned when a chunk does not start where the upload left off.
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match the bytes received so far")
+	// ErrUploadTooLarge is returned for uploads, or chunks, that go past the allowed length.
+	ErrUploadTooLarge = errors.New("upload exceeds the allowed length")
+	// ErrUploadExpired is returned for unfinished uploads that were not resumed in time.
+	ErrUploadExpired = errors.New("upload expired")
+	// ErrUploadBusy is returned for chunks sent while another chunk of the upload is still arriving.
+	ErrUploadBusy = errors.New("upload is receiving another chunk")
+)
+
+// ResumableUploadService receives files in chunks through the tus protocol, so large uploads can
+// resume where they left off after a dropped connection. Chunks are written straight into the
+// file uploader's temp copy; once the last one arrives the upload queue moves it to storage.
+type ResumableUploadService struct {
+	db       *gorm.DB
+	storage  storage.StorageAdapter
+	uploader *uploader.FileUploader
+	queue    *UploadQueue
+	maxSize  int64
+	expiry   time.Duration
+	baseURL  string
+
+	// The uploads receiving a chunk. Files are kept on this server's disk, so
+	// tracking them here is enough to keep chunks from overlapping.
+	mu      sync.Mutex
+	writing map[uuid.UUID]bool
+}
+
+// NewResumableUploadService creates a new resumable upload service.
+func NewResumableUploadService(db *gorm.DB, conf *configs.Config, storageAdapter storage.StorageAdapter, queue *UploadQueue) *ResumableUploadService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, resumableUploadPath)
+	s := &ResumableUploadService{
+		db:       db,
+		storage:  storageAdapter,
+		uploader: fileUploader,
+		queue:    queue,
+		maxSize:  conf.ResumableUploadMaxSize,
+		expiry:   conf.ResumableUploadExpiry,
+		baseURL:  conf.AppURL + "/api/v1/uploads",
+		writing:  make(map[uuid.UUID]bool),
+	}
+	queue.Register(model.UploadTargetResumable, UploadTarget{
+		Done:        resumableUploadStatusUpdater(model.ResumableUploadCloud),
+		Failed:      resumableUploadStatusUpdater(model.ResumableUploadFailed),
+		Quarantined: resumableUploadStatusUpdater(model.ResumableUploadQuarantined),
+		Stalled:     s.stalledUploads,
+	})
+	return s
+}
+
+// MaxSize returns the largest upload accepted, in bytes.
+func (s *ResumableUploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// UploadURL returns the address chunks of the upload are sent to.
+func (s *ResumableUploadService) UploadURL(id uuid.UUID) string {
+	return s.baseURL + "/" + id.String()
+}
+
+// CreateUpload starts an upload of length bytes. metadata is the raw Upload-Metadata header; its
+// filename and filetype entries name the file and set its content type.
+func (s *ResumableUploadService) CreateUpload(userID uuid.UUID, length int64, metadata string) (*model.ResumableUpload, error) {
+	if length < 0 {
+		return nil, errors.New("invalid upload length")
+	}
+	if length > s.maxSize {
+		return nil, ErrUploadTooLarge
+	}
+	if len(metadata) > maxUploadMetadataLength {
+		return nil, fmt.Errorf("invalid upload metadata: must be at most %d characters long", maxUploadMetadataLength)
+	}
+
+	values, err := parseUploadMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	originalName := ""
+	if name := cmp.Or(values["filename"], values["name"]); name != "" {
+		originalName = filepath.Base(name)
+	}
+	contentType := cmp.Or(values["filetype"], values["type"])
+	if len(originalName) > 255 || len(contentType) > 100 {
+		return nil, errors.New("invalid upload metadata: file name or type is too long")
+	}
+
+	upload := &model.ResumableUpload{
+		UserID:       userID,
+		Length:       length,
+		Metadata:     metadata,
+		OriginalName: originalName,
+		ObjectName:   uuid.New().String() + filepath.Ext(originalName),
+		ContentType:  contentType,
+		Status:       model.ResumableUploadUploading,
+		ExpiresAt:    time.Now().Add(s.expiry),
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := upload.Create(tx); err != nil {
+			return err
+		}
+		file, err := os.Create(s.uploader.LocalPath(upload.ObjectName))
+		if err != nil {
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if length == 0 {
+			// An empty file is complete as soon as it exists
+			return s.finish(tx, upload)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// GetUpload retrieves one of the user's uploads, with the address of its file once it is in
+// storage. Unfinished uploads that have expired are reported as such until they are purged.
+func (s *ResumableUploadService) GetUpload(id, userID uuid.UUID) (*model.ResumableUpload, error) {
+	upload, err := new(model.ResumableUpload).FindByID(s.db, id)
+	if err != nil || upload.UserID != userID {
+		return nil, errors.New("upload not found")
+	}
+	if upload.Status == model.ResumableUploadUploading && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	if upload.Status == model.ResumableUploadCloud {
+		upload.URL = s.storage.URL(upload.ObjectName)
+	}
+	return upload, nil
+}
+
+// WriteChunk appends a chunk to the upload. offset must equal the bytes received so far, and size
+// is the length of the chunk, or -1 if it is not known up front. The chunk is streamed straight
+// into the file, so it may be as large as the rest of the upload. Chunks of one upload are written
+// one at a time, and a chunk counts only once it is on disk, so a chunk cut off part way can simply
+// be sent again. The chunk that completes the file hands it to the upload queue.
+func (s *ResumableUploadService) WriteChunk(id, userID uuid.UUID, offset, size int64, chunk io.Reader) (*model.ResumableUpload, error) {
+	if !s.claim(id) {
+		return nil, ErrUploadBusy
+	}
+	defer s.release(id)
+
+	upload, err := new(model.ResumableUpload).FindByID(s.db, id)
+	if err != nil || upload.UserID != userID {
+		return nil, errors.New("upload not found")
+	}
+	if upload.Status == model.ResumableUploadUploading && time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadExpired
+	}
+	if offset != upload.Offset {
+		return nil, ErrUploadOffsetMismatch
+	}
+	if size > upload.Length-upload.Offset {
+		return nil, ErrUploadTooLarge
+	}
+	if upload.Status != model.ResumableUploadUploading {
+		// Already complete, so only an empty chunk fits
+		if hasMore(chunk) {
+			return nil, ErrUploadTooLarge
+		}
+		return upload, nil
+	}
+
+	// The file is written outside the transaction, so a slow client does not hold the record locked
+	written, err := writeChunk(s.uploader.LocalPath(upload.ObjectName), chunk, offset, upload.Length-upload.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		// The upload may have been terminated or purged while the chunk arrived
+		upload, err = new(model.ResumableUpload).FindByIDForUpdate(tx, id)
+		if err != nil {
+			return errors.New("upload not found")
+		}
+		if upload.Status != model.ResumableUploadUploading || upload.Offset != offset {
+			return ErrUploadOffsetMismatch
+		}
+		if err := upload.UpdateProgress(tx, offset+written, time.Now().Add(s.expiry)); err != nil {
+			return err
+		}
+		if upload.Offset == upload.Length {
+			return s.finish(tx, upload)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// TerminateUpload deletes one of the user's uploads along with its file.
+func (s *ResumableUploadService) TerminateUpload(ctx context.Context, id, userID uuid.UUID) error {
+	var upload *model.ResumableUpload
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		upload, err = new(model.ResumableUpload).FindByIDForUpdate(tx, id)
+		if err != nil || upload.UserID != userID {
+			return errors.New("upload not found")
+		}
+		return upload.Delete(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The file goes only once the record is gone for good
+	if err := s.uploader.Delete(ctx, upload.ObjectName); err != nil {
+		slog.Error("Error deleting resumable upload file", "file", upload.ObjectName, "error", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes unfinished uploads that were not resumed before they expired.
+func (s *ResumableUploadService) PurgeExpired(ctx context.Context) {
+	var found model.ResumableUpload
+	expired, err := found.FindExpired(s.db.WithContext(ctx), time.Now(), expiredUploadBatchSize)
+	if err != nil {
+		slog.Error("Failed to find expired uploads", "error", err)
+		return
+	}
+
+	purged := 0
+	for _, candidate := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var upload *model.ResumableUpload
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			// A chunk may have arrived since the upload was found
+			upload, err = new(model.ResumableUpload).FindByIDForUpdate(tx, candidate.ID)
+			if err != nil || upload.Status != model.ResumableUploadUploading || time.Now().Before(upload.ExpiresAt) {
+				upload = nil
+				return nil
+			}
+			return upload.Delete(tx)
+		})
+		if err != nil {
+			slog.Error("Failed to purge expired upload", "uploadID", candidate.ID, "error", err)
+			continue
+		}
+		if upload == nil {
+			continue
+		}
+
+		err = os.Remove(s.uploader.LocalPath(upload.ObjectName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("Error deleting expired upload file", "file", upload.ObjectName, "error", err)
+		}
+		purged++
+	}
+	if purged > 0 {
+		slog.Info("Purged expired uploads", "count", purged)
+	}
+}
+
+// finish hands a complete file to the upload queue. It must run in the transaction
+// that records the last chunk.
+func (s *ResumableUploadService) finish(tx *gorm.DB, upload *model.ResumableUpload) error {
+	if err := upload.UpdateStatus(tx, model.ResumableUploadLocal); err != nil {
+		return err
+	}
+	upload.Status = model.ResumableUploadLocal
+	return s.queue.Enqueue(tx, s.uploadJob(upload))
+}
+
+// uploadJob describes the move of a finished upload to storage.
+func (s *ResumableUploadService) uploadJob(upload *model.ResumableUpload) *model.UploadJob {
+	return &model.UploadJob{
+		TargetType:  model.UploadTargetResumable,
+		TargetID:    upload.ID,
+		ObjectName:  upload.ObjectName,
+		LocalPath:   s.uploader.LocalPath(upload.ObjectName),
+		ContentType: upload.ContentType,
+		Size:        upload.Length,
+	}
+}
+
+// resumableUploadStatusUpdater records the outcome of moving a finished upload to storage.
+func resumableUploadStatusUpdater(status string) func(tx *gorm.DB, job *model.UploadJob) error {
+	return func(tx *gorm.DB, job *model.UploadJob) error {
+		upload := model.ResumableUpload{ID: job.TargetID}
+		return upload.UpdateStatus(tx, status)
+	}
+}
+
+// stalledUploads lists the finished uploads the upload queue lost track of.
+func (s *ResumableUploadService) stalledUploads(db *gorm.DB) ([]model.UploadJob, error) {
+	var found model.ResumableUpload
+	uploads, err := found.FindStalled(db)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]model.UploadJob, len(uploads))
+	for i := range uploads {
+		jobs[i] = *s.uploadJob(&uploads[i])
+	}
+	return jobs, nil
+}
+
+// parseUploadMetadata decodes a tus Upload-Metadata header: comma-separated pairs of
+// a key and an optional base64-encoded value, separated by a space.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	values := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return values, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("invalid upload metadata: empty key")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload metadata: value of %q is not base64", key)
+		}
+		values[key] = string(value)
+	}
+	return values, nil
+}
+
+// claim marks an upload as receiving a chunk, reporting false if it already is.
+func (s *ResumableUploadService) claim(id uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writing[id] {
+		return false
+	}
+	s.writing[id] = true
+	return true
+}
+
+// release marks an upload as no longer receiving a chunk.
+func (s *ResumableUploadService) release(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.writing, id)
+}
+
+// writeChunk streams a chunk into a file at the given offset and flushes it to disk, returning the
+// bytes written. A chunk longer than limit is rejected with ErrUploadTooLarge.
+func writeChunk(path string, chunk io.Reader, offset, limit int64) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(io.NewOffsetWriter(file, offset), io.LimitReader(chunk, limit))
+	if err == nil && hasMore(chunk) {
+		err = ErrUploadTooLarge
+	}
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return 0, err
+	}
+	return written, file.Close()
+}
+
+// hasMore reports whether a reader has bytes left to read.
+func hasMore(r io.Reader) bool {
+	n, _ := io.ReadFull(r, make([]byte, 1))
+	return n > 0
+}
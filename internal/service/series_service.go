@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"venturo-core/internal/model"
+	"venturo-core/internal/policy"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SeriesService struct {
+	db *gorm.DB
+}
+
+// NewSeriesService creates a new series service.
+func NewSeriesService(db *gorm.DB) *SeriesService {
+	return &SeriesService{db: db}
+}
+
+// CreateSeries creates a new, empty series for the user.
+func (s *SeriesService) CreateSeries(userID uuid.UUID, title, description string) (*model.Series, error) {
+	series := model.Series{UserID: userID, Title: title, Description: description}
+	if err := series.Save(s.db); err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// GetSeries retrieves a series with its posts in reading order.
+// Only the posts the viewer may read are included.
+func (s *SeriesService) GetSeries(id, viewerID uuid.UUID) (*model.Series, error) {
+	var found model.Series
+	series, err := found.FindByID(s.db, id)
+	if err != nil {
+		return nil, errors.New("series not found")
+	}
+
+	var post model.Post
+	if series.Posts, err = post.FindAllInSeries(s.db.Scopes(model.ReadableBy(viewerID)), id); err != nil {
+		return nil, err
+	}
+
+	if viewerID != uuid.Nil {
+		refs := make([]*model.Post, len(series.Posts))
+		for i := range series.Posts {
+			refs[i] = &series.Posts[i]
+		}
+		if err := markBookmarked(s.db, viewerID, refs); err != nil {
+			return nil, err
+		}
+	}
+	return series, nil
+}
+
+// UpdateSeries changes the title and description of one of the user's series.
+func (s *SeriesService) UpdateSeries(userID, id uuid.UUID, title, description string) (*model.Series, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		series, err := findOwnedSeriesForUpdate(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		series.Title = title
+		series.Description = description
+		return series.Save(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(id, userID)
+}
+
+// DeleteSeries removes one of the user's series. Its posts are kept.
+func (s *SeriesService) DeleteSeries(userID, id uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		series, err := findOwnedSeriesForUpdate(tx, id, userID)
+		if err != nil {
+			return err
+		}
+		return series.Delete(tx)
+	})
+}
+
+// AddPost appends a post the user may manage to the end of their series.
+// A post can only belong to one series at a time.
+func (s *SeriesService) AddPost(userID, seriesID, postID uuid.UUID) (*model.Series, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the series so concurrent additions get distinct positions
+		if _, err := findOwnedSeriesForUpdate(tx, seriesID, userID); err != nil {
+			return err
+		}
+
+		var post model.Post
+		found, err := post.FindByID(tx, postID)
+		if err != nil {
+			return errors.New("post not found")
+		}
+		if err := policy.AuthorizePost(tx, found, userID, policy.ManagePost); err != nil {
+			return err
+		}
+
+		var membership model.SeriesPost
+		if existing, err := membership.FindByPostID(tx, postID); err == nil {
+			if existing.SeriesID == seriesID {
+				return errors.New("post is already in this series")
+			}
+			return errors.New("post already belongs to another series")
+		}
+
+		membership = model.SeriesPost{SeriesID: seriesID, PostID: postID}
+		return membership.Add(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(seriesID, userID)
+}
+
+// RemovePost takes a post out of one of the user's series.
+func (s *SeriesService) RemovePost(userID, seriesID, postID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findOwnedSeriesForUpdate(tx, seriesID, userID); err != nil {
+			return err
+		}
+
+		var found model.SeriesPost
+		membership, err := found.FindByPostID(tx, postID)
+		if err != nil || membership.SeriesID != seriesID {
+			return errors.New("post not found in this series")
+		}
+		return membership.Remove(tx)
+	})
+}
+
+// ReorderPosts puts the posts of one of the user's series in the given order.
+// Every post shown in the series must be listed exactly once. Posts in the trash
+// are not shown and move behind the others, keeping their relative order.
+func (s *SeriesService) ReorderPosts(userID, seriesID uuid.UUID, postIDs []uuid.UUID) (*model.Series, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := findOwnedSeriesForUpdate(tx, seriesID, userID); err != nil {
+			return err
+		}
+
+		var post model.Post
+		shown, err := post.FindSeriesEntries(tx, seriesID)
+		if err != nil {
+			return err
+		}
+		if len(postIDs) != len(shown) {
+			return errors.New("invalid order: every post of the series must be listed exactly once")
+		}
+
+		isShown := make(map[uuid.UUID]bool, len(shown))
+		for _, entry := range shown {
+			isShown[entry.PostID] = true
+		}
+
+		var membership model.SeriesPost
+		memberships, err := membership.FindAllBySeriesID(tx, seriesID)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uuid.UUID]*model.SeriesPost, len(memberships))
+		for i := range memberships {
+			byID[memberships[i].PostID] = &memberships[i]
+		}
+
+		for position, id := range postIDs {
+			item, ok := byID[id]
+			if !ok || !isShown[id] {
+				return errors.New("invalid order: every post of the series must be listed exactly once")
+			}
+			delete(byID, id) // A repeated ID will not be found a second time
+
+			if item.Position != position {
+				if err := item.UpdatePosition(tx, position); err != nil {
+					return err
+				}
+			}
+		}
+
+		// What is left are the trashed posts, still in their old order
+		position := len(postIDs)
+		for i := range memberships {
+			item, ok := byID[memberships[i].PostID]
+			if !ok {
+				continue
+			}
+			if item.Position != position {
+				if err := item.UpdatePosition(tx, position); err != nil {
+					return err
+				}
+			}
+			position++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(seriesID, userID)
+}
+
+// findOwnedSeriesForUpdate locks a series and ensures the given user is its author.
+// It must run inside a transaction.
+func findOwnedSeriesForUpdate(tx *gorm.DB, seriesID, userID uuid.UUID) (*model.Series, error) {
+	var found model.Series
+	series, err := found.FindByIDForUpdate(tx, seriesID)
+	if err != nil {
+		return nil, errors.New("series not found")
+	}
+
+	if err := policy.AuthorizeSeries(series, userID); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// seriesNavigation tells where the post sits in its series, as seen by the viewer.
+// It returns nil for posts that do not belong to a series.
+func seriesNavigation(db *gorm.DB, viewerID, postID uuid.UUID) (*model.SeriesNavigation, error) {
+	var membership model.SeriesPost
+	found, err := membership.FindByPostID(db, postID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var seriesModel model.Series
+	series, err := seriesModel.FindByID(db, found.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	var post model.Post
+	entries, err := post.FindSeriesEntries(db.Scopes(model.ReadableBy(viewerID)), series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		if entry.PostID != postID {
+			continue
+		}
+
+		navigation := &model.SeriesNavigation{ID: series.ID, Title: series.Title, Part: i + 1, Total: len(entries)}
+		if i > 0 {
+			navigation.Previous = &entries[i-1]
+		}
+		if i+1 < len(entries) {
+			navigation.Next = &entries[i+1]
+		}
+		return navigation, nil
+	}
+	return nil, nil // The viewer cannot read the post itself
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/uploader"
+
+	"gorm.io/gorm"
+)
+
+// storedFileUploadPath is the temporary local storage path for stored files. It is kept out of
+// ./public, so files cannot be downloaded before they have been scanned.
+const storedFileUploadPath = "./uploads/files"
+
+// unreferencedFileGrace is how long a file nobody uses is kept before it is deleted,
+// so uploading it again soon after finds it still stored.
+const unreferencedFileGrace = time.Hour
+
+// unreferencedFileBatchSize caps the files a single collection deletes.
+const unreferencedFileBatchSize = 100
+
+// ErrFileQuarantined is returned for content the malware scanner flagged before.
+var ErrFileQuarantined = errors.New("invalid file: it was quarantined by the malware scanner")
+
+// StoredObject is one object of a file being stored, such as one size of an image.
+type StoredObject struct {
+	Name        string
+	Data        []byte
+	ContentType string
+}
+
+// StoredFileService keeps uploaded content in storage once, however many records use it. Files
+// are looked up by the hash of their content and reference counted; files nobody uses any more
+// are deleted in the background.
+type StoredFileService struct {
+	db        *gorm.DB
+	uploader  *uploader.FileUploader
+	queue     *UploadQueue
+	listeners []func(tx *gorm.DB, file *model.StoredFile) error
+}
+
+// NewStoredFileService creates a new stored file service.
+func NewStoredFileService(db *gorm.DB, storageAdapter storage.StorageAdapter, queue *UploadQueue) *StoredFileService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, storedFileUploadPath)
+	s := &StoredFileService{db: db, uploader: fileUploader, queue: queue}
+	queue.Register(model.UploadTargetStoredFile, UploadTarget{
+		Done:        s.completeFile,
+		Failed:      s.failFile,
+		Quarantined: s.quarantineFile,
+		Stalled:     s.stalledFiles,
+	})
+	return s
+}
+
+// OnStatusChange adds a function called when a file is stored or its upload fails, in the
+// transaction that records it, so records using the file can follow its status.
+func (s *StoredFileService) OnStatusChange(fn func(tx *gorm.DB, file *model.StoredFile) error) {
+	s.listeners = append(s.listeners, fn)
+}
+
+// Acquire takes a reference for the caller to the file holding the content candidate describes,
+// recording candidate if the content is new. It reports whether the content is stored or on its
+// way to storage; if not, the returned record, which may be candidate or a failed file described
+// like it, is passed to Store. Content that was quarantined is refused with ErrFileQuarantined.
+// It must run inside a transaction.
+func (s *StoredFileService) Acquire(tx *gorm.DB, candidate *model.StoredFile) (*model.StoredFile, bool, error) {
+	created, err := candidate.CreateOrReference(tx)
+	if err != nil {
+		return nil, false, err
+	}
+	if created {
+		return candidate, false, nil
+	}
+
+	file, err := new(model.StoredFile).FindBySHA256ForUpdate(tx, candidate.SHA256)
+	if err != nil {
+		return nil, false, err
+	}
+	switch file.Status {
+	case model.StoredFileQuarantined:
+		return nil, false, ErrFileQuarantined
+	case model.StoredFileFailed:
+		file.ObjectName = candidate.ObjectName
+		file.Variants = candidate.Variants
+		file.ContentType = candidate.ContentType
+		file.Size = candidate.Size
+		return file, false, nil
+	}
+	return file, true, nil
+}
+
+// Store saves the objects of a file Acquire found missing on the server, queues their move to
+// storage and records the file as local. It must run inside a transaction.
+func (s *StoredFileService) Store(tx *gorm.DB, file *model.StoredFile, objects []StoredObject) error {
+	file.Status = model.StoredFileLocal
+	if err := file.Save(tx); err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		localPath, err := s.uploader.SaveLocal(bytes.NewReader(object.Data), object.Name)
+		if err != nil {
+			slog.Error("Error saving temp file", "file", object.Name, "error", err)
+			return err
+		}
+		err = s.queue.Enqueue(tx, &model.UploadJob{
+			TargetType:  model.UploadTargetStoredFile,
+			TargetID:    file.ID,
+			ObjectName:  object.Name,
+			LocalPath:   localPath,
+			ContentType: object.ContentType,
+			Size:        int64(len(object.Data)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Release drops a reference to the file stored under the given name. It reports false if no
+// such file is recorded, as for files stored before they were tracked. It must run inside a
+// transaction.
+func (s *StoredFileService) Release(tx *gorm.DB, objectName string) (bool, error) {
+	if objectName == "" {
+		return false, nil
+	}
+
+	file, err := new(model.StoredFile).FindByObjectNameForUpdate(tx, objectName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, file.Release(tx)
+}
+
+// CollectGarbage deletes files nobody has used for a while, both from storage and the server.
+func (s *StoredFileService) CollectGarbage(ctx context.Context) {
+	var found model.StoredFile
+	unused, err := found.FindUnreferenced(s.db.WithContext(ctx), time.Now().Add(-unreferencedFileGrace), unreferencedFileBatchSize)
+	if err != nil {
+		slog.Error("Failed to find unreferenced files", "error", err)
+		return
+	}
+
+	deleted := 0
+	for _, candidate := range unused {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var file *model.StoredFile
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			// The file may have been uploaded again since it was found
+			file, err = new(model.StoredFile).FindByIDForUpdate(tx, candidate.ID)
+			if err != nil || file.RefCount > 0 {
+				file = nil
+				return err
+			}
+
+			// An upload still under way would store the object again after it is deleted
+			job := model.UploadJob{}
+			open, err := job.CountOpen(tx, file.ID, file.Objects())
+			if err != nil || open > 0 {
+				file = nil
+				return err
+			}
+			return file.Delete(tx)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to delete unreferenced file", "fileID", candidate.ID, "error", err)
+			continue
+		}
+		if file == nil {
+			continue
+		}
+
+		for _, objectName := range file.Objects() {
+			if err := s.uploader.Delete(ctx, objectName); err != nil {
+				slog.Error("Error deleting unreferenced file", "file", objectName, "error", err)
+			}
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		slog.Info("Deleted unreferenced files", "count", deleted)
+	}
+}
+
+// completeFile marks the file as stored once the last of its objects is.
+func (s *StoredFileService) completeFile(tx *gorm.DB, job *model.UploadJob) error {
+	file, err := new(model.StoredFile).FindByIDForUpdate(tx, job.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if file.Status == model.StoredFileFailed || file.Status == model.StoredFileQuarantined {
+		return nil
+	}
+
+	open, err := job.CountOpen(tx, file.ID, file.Objects())
+	if err != nil || open > 0 {
+		return err
+	}
+	return s.setStatus(tx, file, model.StoredFileCloud)
+}
+
+// failFile marks the file as failed when any of its objects cannot be stored.
+func (s *StoredFileService) failFile(tx *gorm.DB, job *model.UploadJob) error {
+	file, err := new(model.StoredFile).FindByIDForUpdate(tx, job.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.setStatus(tx, file, model.StoredFileFailed)
+}
+
+// quarantineFile marks the file as quarantined when any of its objects is infected.
+func (s *StoredFileService) quarantineFile(tx *gorm.DB, job *model.UploadJob) error {
+	file, err := new(model.StoredFile).FindByIDForUpdate(tx, job.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.setStatus(tx, file, model.StoredFileQuarantined)
+}
+
+// setStatus records the file's status and lets the records using it follow.
+func (s *StoredFileService) setStatus(tx *gorm.DB, file *model.StoredFile, status string) error {
+	if err := file.UpdateStatus(tx, status); err != nil {
+		return err
+	}
+	file.Status = status
+	for _, listener := range s.listeners {
+		if err := listener(tx, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stalledFiles lists the file uploads the upload queue lost track of.
+func (s *StoredFileService) stalledFiles(db *gorm.DB) ([]model.UploadJob, error) {
+	var found model.StoredFile
+	files, err := found.FindStalled(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []model.UploadJob
+	for _, file := range files {
+		for _, objectName := range file.Objects() {
+			jobs = append(jobs, model.UploadJob{
+				TargetID:   file.ID,
+				ObjectName: objectName,
+				LocalPath:  s.uploader.LocalPath(objectName),
+			})
+		}
+	}
+	return jobs, nil
+}
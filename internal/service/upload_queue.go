@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/scanner"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// uploadBatchSize caps the jobs a single run of the queue works through.
+const uploadBatchSize = 20
+
+// maxUploadRetryDelay caps the backoff between attempts.
+const maxUploadRetryDelay = time.Hour
+
+// quarantinePath holds infected files, out of the directories served under /public.
+const quarantinePath = "./uploads/quarantine"
+
+// legacyUploadPaths maps the directories files used to wait in on the server, under the served
+// ./public, to those that replaced them.
+var legacyUploadPaths = map[string]string{
+	"./public/uploads/avatars":     tempUploadPath,
+	"./public/uploads/attachments": attachmentUploadPath,
+	"./public/uploads/files":       storedFileUploadPath,
+}
+
+// errFileInfected is returned for files the malware scanner flags.
+var errFileInfected = errors.New("file is infected")
+
+// UploadTarget handles the uploads of one kind of record, such as avatars.
+type UploadTarget struct {
+	// Done records that the file is in storage. It runs in the transaction that removes the job.
+	Done func(tx *gorm.DB, job *model.UploadJob) error
+	// Failed records that the upload was given up on.
+	Failed func(tx *gorm.DB, job *model.UploadJob) error
+	// Quarantined records that the file is infected, or too large to scan, and will not be published.
+	Quarantined func(tx *gorm.DB, job *model.UploadJob) error
+	// Stalled lists the uploads of records left mid-upload without a job, so they can be resumed.
+	Stalled func(db *gorm.DB) ([]model.UploadJob, error)
+}
+
+// UploadQueue moves files from the server's disk to storage in the background. The work is
+// recorded as jobs in the database; failed attempts are retried with exponential backoff
+// until they run out, after which the job is left as dead. Every file is scanned for malware
+// before it is stored; infected files, and those the scanner refuses as too large, are
+// quarantined instead. Files uploaded straight to storage go through the same scan while
+// staged, and are only moved to where they can be reached once it passes.
+type UploadQueue struct {
+	db          *gorm.DB
+	storage     storage.StorageAdapter
+	scanner     scanner.Scanner
+	maxAttempts int
+	retryDelay  time.Duration
+	targets     map[string]UploadTarget
+}
+
+// NewUploadQueue creates a new upload queue. Services register their targets before it starts.
+func NewUploadQueue(db *gorm.DB, storage storage.StorageAdapter, scanner scanner.Scanner, conf *configs.Config) *UploadQueue {
+	return &UploadQueue{
+		db:          db,
+		storage:     storage,
+		scanner:     scanner,
+		maxAttempts: conf.UploadMaxAttempts,
+		retryDelay:  conf.UploadRetryDelay,
+		targets:     make(map[string]UploadTarget),
+	}
+}
+
+// Register sets how the uploads of the given target type are completed.
+func (q *UploadQueue) Register(targetType string, target UploadTarget) {
+	q.targets[targetType] = target
+}
+
+// Enqueue records an upload. Pass the transaction that records the file, so the two commit together.
+func (q *UploadQueue) Enqueue(tx *gorm.DB, job *model.UploadJob) error {
+	return job.Create(tx)
+}
+
+// Recover resumes the uploads interrupted by the last shutdown: running jobs go back in the
+// queue, and records left mid-upload without a job get one if their file is still on disk.
+// Files still waiting in the legacy directories are moved out of ./public first.
+func (q *UploadQueue) Recover(ctx context.Context) {
+	q.moveLegacyUploads()
+
+	var found model.UploadJob
+	reset, err := found.ResetRunning(q.db)
+	if err != nil {
+		slog.Error("Failed to reset interrupted upload jobs", "error", err)
+	} else if reset > 0 {
+		slog.Info("Resumed interrupted upload jobs", "count", reset)
+	}
+
+	for targetType, target := range q.targets {
+		stalled, err := target.Stalled(q.db.WithContext(ctx))
+		if err != nil {
+			slog.Error("Failed to find stalled uploads", "target", targetType, "error", err)
+			continue
+		}
+
+		for i := range stalled {
+			job := &stalled[i]
+			job.TargetType = targetType
+			if _, err := os.Stat(job.LocalPath); err != nil {
+				// The file never made it to disk, so there is nothing left to upload
+				err = q.db.Transaction(func(tx *gorm.DB) error {
+					return target.Failed(tx, job)
+				})
+			} else {
+				err = q.Enqueue(q.db, job)
+			}
+			if err != nil {
+				slog.Error("Failed to recover stalled upload", "target", targetType, "targetID", job.TargetID, "error", err)
+			}
+		}
+		if len(stalled) > 0 {
+			slog.Info("Recovered stalled uploads", "target", targetType, "count", len(stalled))
+		}
+	}
+}
+
+// moveLegacyUploads moves the files left in the legacy upload directories, where they could be
+// downloaded before being scanned, to the directories that replaced them, along with their jobs.
+// Files that cannot be moved are left for the orphan collector, which also looks there.
+func (q *UploadQueue) moveLegacyUploads() {
+	for legacyPath, uploadPath := range legacyUploadPaths {
+		entries, err := os.ReadDir(legacyPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			err = os.MkdirAll(uploadPath, os.ModePerm)
+		}
+		if err != nil {
+			slog.Error("Failed to move legacy uploads", "dir", legacyPath, "error", err)
+			continue
+		}
+
+		moved := 0
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			oldPath := filepath.Join(legacyPath, entry.Name())
+			newPath := filepath.Join(uploadPath, entry.Name())
+			// The jobs only point at the new path once the file is there
+			err := q.db.Transaction(func(tx *gorm.DB) error {
+				var job model.UploadJob
+				if err := job.MoveLocalPath(tx, oldPath, newPath); err != nil {
+					return err
+				}
+				return os.Rename(oldPath, newPath)
+			})
+			if err != nil {
+				slog.Error("Failed to move legacy upload", "file", oldPath, "error", err)
+				continue
+			}
+			moved++
+		}
+		if moved > 0 {
+			slog.Info("Moved legacy uploads", "from", legacyPath, "to", uploadPath, "count", moved)
+		}
+	}
+}
+
+// Process runs the jobs that are due.
+func (q *UploadQueue) Process(ctx context.Context) {
+	var found model.UploadJob
+	jobs, err := found.FindDue(q.db.WithContext(ctx), time.Now(), uploadBatchSize)
+	if err != nil {
+		slog.Error("Failed to find due upload jobs", "error", err)
+		return
+	}
+
+	for i := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		q.run(ctx, &jobs[i])
+	}
+}
+
+// run makes one attempt at a job.
+func (q *UploadQueue) run(ctx context.Context, job *model.UploadJob) {
+	claimed, err := job.Claim(q.db)
+	if err != nil || !claimed {
+		if err != nil {
+			slog.Error("Failed to claim upload job", "jobID", job.ID, "error", err)
+		}
+		return
+	}
+
+	target, ok := q.targets[job.TargetType]
+	if !ok {
+		if err := job.Kill(q.db, "unknown target type "+job.TargetType); err != nil {
+			slog.Error("Failed to mark upload job dead", "jobID", job.ID, "error", err)
+		}
+		return
+	}
+
+	uploadErr := q.upload(ctx, job)
+	switch {
+	case uploadErr == nil:
+		err := q.db.Transaction(func(tx *gorm.DB) error {
+			if err := target.Done(tx, job); err != nil {
+				return err
+			}
+			return job.Delete(tx)
+		})
+		if err != nil {
+			// The file is stored, so the next attempt only has to record it
+			slog.Error("Failed to complete upload job", "jobID", job.ID, "error", err)
+			q.retry(job, err)
+			return
+		}
+		if !job.Staged {
+			if err := os.Remove(job.LocalPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				slog.Error("Error cleaning up temp file", "file", job.LocalPath, "error", err)
+			}
+		}
+		slog.Info("Successfully uploaded to cloud", "file", job.ObjectName, "attempts", job.Attempts)
+
+	case errors.Is(uploadErr, errFileInfected) || errors.Is(uploadErr, scanner.ErrTooLarge):
+		q.quarantine(ctx, job, target, uploadErr)
+
+	case ctx.Err() != nil:
+		// Shutting down; the attempt does not count against the job
+		if err := job.Release(q.db); err != nil {
+			slog.Error("Failed to release upload job", "jobID", job.ID, "error", err)
+		}
+
+	case errors.Is(uploadErr, fs.ErrNotExist) || errors.Is(uploadErr, storage.ErrObjectNotFound) || job.Attempts >= q.maxAttempts:
+		// The temp or staged file is kept, so a dead upload can still be looked into
+		slog.Error("Giving up on upload", "file", job.ObjectName, "attempts", job.Attempts, "error", uploadErr)
+		err := q.db.Transaction(func(tx *gorm.DB) error {
+			if err := job.Kill(tx, uploadErr.Error()); err != nil {
+				return err
+			}
+			return target.Failed(tx, job)
+		})
+		if err != nil {
+			slog.Error("Failed to mark upload job dead", "jobID", job.ID, "error", err)
+		}
+
+	default:
+		slog.Error("Error uploading to cloud", "file", job.ObjectName, "attempts", job.Attempts, "error", uploadErr)
+		q.retry(job, uploadErr)
+	}
+}
+
+// upload scans the job's temp file for malware, then copies it to storage.
+func (q *UploadQueue) upload(ctx context.Context, job *model.UploadJob) error {
+	if job.Staged {
+		return q.publish(ctx, job)
+	}
+
+	file, err := os.Open(job.LocalPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := q.scan(ctx, job, file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	meta := storage.ObjectMeta{ContentType: job.ContentType, Size: job.Size}
+	return q.storage.Put(ctx, job.ObjectName, file, meta)
+}
+
+// publish scans a staged file for malware, reading it back from storage, then moves it from its
+// held key to its object name. Nothing can write to the held key, so the scanned bytes are the
+// ones that get published.
+func (q *UploadQueue) publish(ctx context.Context, job *model.UploadJob) error {
+	heldKey := storage.HeldKey(job.ObjectName)
+	file, _, err := q.storage.Get(ctx, heldKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		// An earlier attempt may have moved the file but failed to record it
+		if _, statErr := q.storage.Stat(ctx, job.ObjectName); statErr == nil {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = q.scan(ctx, job, file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return q.storage.Move(ctx, heldKey, job.ObjectName)
+}
+
+// scan checks the content of the job's file for malware.
+func (q *UploadQueue) scan(ctx context.Context, job *model.UploadJob, content io.Reader) error {
+	result, err := q.scanner.Scan(ctx, content)
+	if err != nil {
+		return fmt.Errorf("scanning file: %w", err)
+	}
+	if result.Infected {
+		return fmt.Errorf("%w: %s", errFileInfected, result.Signature)
+	}
+	if result.Partial {
+		slog.Warn("Storing file scanned only in part, as it is over the scanner's size limit", "file", job.ObjectName, "size", job.Size)
+	}
+	return nil
+}
+
+// quarantine moves a file that must not be published out of the upload directories, or out of
+// storage if it was staged there, and gives up on its job for good. The file is kept on the
+// server, so it can still be looked into.
+func (q *UploadQueue) quarantine(ctx context.Context, job *model.UploadJob, target UploadTarget, cause error) {
+	slog.Warn("Quarantining upload", "file", job.ObjectName, "error", cause)
+
+	var localPath string
+	if job.Staged {
+		localPath = q.quarantineStaged(ctx, job)
+	} else {
+		localPath = quarantineLocal(job)
+	}
+
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		if err := job.Quarantine(tx, localPath, cause.Error()); err != nil {
+			return err
+		}
+		return target.Quarantined(tx, job)
+	})
+	if err != nil {
+		slog.Error("Failed to mark upload job quarantined", "jobID", job.ID, "error", err)
+	}
+}
+
+// quarantineLocal moves the job's temp file to the quarantine directory, returning its new path,
+// or "" if it had to be deleted instead.
+func quarantineLocal(job *model.UploadJob) string {
+	localPath := filepath.Join(quarantinePath, job.ID.String()+"_"+filepath.Base(job.LocalPath))
+	err := os.MkdirAll(quarantinePath, 0o700)
+	if err == nil {
+		err = os.Rename(job.LocalPath, localPath)
+	}
+	if err != nil {
+		// A file that may be infected must not stay where it would be picked up again
+		slog.Error("Error moving file to quarantine, deleting it", "file", job.LocalPath, "error", err)
+		if err := os.Remove(job.LocalPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("Error deleting quarantined file", "file", job.LocalPath, "error", err)
+		}
+		return ""
+	}
+	return localPath
+}
+
+// quarantineStaged copies the job's staged file to the quarantine directory, then deletes it from
+// storage. It returns the path of the copy, or "" if the file could only be deleted.
+func (q *UploadQueue) quarantineStaged(ctx context.Context, job *model.UploadJob) string {
+	heldKey := storage.HeldKey(job.ObjectName)
+	localPath := filepath.Join(quarantinePath, job.ID.String()+"_"+filepath.Base(job.ObjectName))
+	if err := q.download(ctx, heldKey, localPath); err != nil {
+		slog.Error("Error copying file to quarantine, deleting it", "file", heldKey, "error", err)
+		localPath = ""
+	}
+	// Staged files are out of public reach, but one that may be infected must not stay in storage
+	if err := q.storage.Delete(ctx, heldKey); err != nil {
+		slog.Error("Error deleting quarantined file", "file", heldKey, "error", err)
+	}
+	return localPath
+}
+
+// download copies a stored object to a new file on the server's disk.
+func (q *UploadQueue) download(ctx context.Context, key, localPath string) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o700); err != nil {
+		return err
+	}
+	content, _, err := q.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(localPath)
+	}
+	return err
+}
+
+// retry schedules the next attempt, doubling the delay after each failure.
+func (q *UploadQueue) retry(job *model.UploadJob, cause error) {
+	delay := q.retryDelay
+	for i := 1; i < job.Attempts && delay < maxUploadRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxUploadRetryDelay)
+
+	reason := fmt.Sprintf("attempt %d: %v", job.Attempts, cause)
+	if err := job.Retry(q.db, time.Now().Add(delay), reason); err != nil {
+		slog.Error("Failed to reschedule upload job", "jobID", job.ID, "error", err)
+	}
+}
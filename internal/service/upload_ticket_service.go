@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+	"venturo-core/configs"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// uploadTicketGrace is how long after its link expires a ticket can still be completed,
+// so an upload that started just before the link expired has time to finish.
+const uploadTicketGrace = time.Hour
+
+// expiredTicketBatchSize caps the expired tickets a single cleanup removes.
+const expiredTicketBatchSize = 100
+
+// ErrUploadTicketExpired is returned for tickets that were not completed in time.
+var ErrUploadTicketExpired = errors.New("upload ticket expired")
+
+// errNothingUploaded is returned when a ticket is completed before its file was uploaded.
+var errNothingUploaded = errors.New("invalid upload: no file has been uploaded for this ticket")
+
+// UploadTicketService lets clients upload files straight to storage through signed links, so
+// large files never pass through the API. A ticket records the file the client means to send,
+// which is uploaded under its staging key, out of public reach; completing the ticket checks
+// what arrived before moving it into place and linking it to the user or a post.
+type UploadTicketService struct {
+	db          *gorm.DB
+	storage     storage.StorageAdapter
+	users       *UserService
+	attachments *AttachmentService
+	maxSize     int64
+	expiry      time.Duration
+}
+
+// NewUploadTicketService creates a new upload ticket service.
+func NewUploadTicketService(db *gorm.DB, conf *configs.Config, storageAdapter storage.StorageAdapter, users *UserService, attachments *AttachmentService) *UploadTicketService {
+	return &UploadTicketService{
+		db:          db,
+		storage:     storageAdapter,
+		users:       users,
+		attachments: attachments,
+		maxSize:     conf.UploadTicketMaxSize,
+		expiry:      conf.UploadTicketExpiry,
+	}
+}
+
+// CreateTicket issues a ticket for the file described, along with the signed link it is
+// uploaded to. Attachment tickets need a post the user may edit.
+func (s *UploadTicketService) CreateTicket(ctx context.Context, userID uuid.UUID, ticket *model.UploadTicket) (*model.UploadTicket, error) {
+	ticket.ChecksumSHA256 = strings.ToLower(ticket.ChecksumSHA256)
+	if sum, err := hex.DecodeString(ticket.ChecksumSHA256); err != nil || len(sum) != sha256.Size {
+		return nil, errors.New("invalid checksum: must be the hex-encoded SHA-256 of the file")
+	}
+	if ticket.Size <= 0 {
+		return nil, errors.New("invalid size: the file must not be empty")
+	}
+
+	switch ticket.Purpose {
+	case model.UploadTicketAvatar:
+		if ticket.PostID != nil {
+			return nil, errors.New("invalid ticket: avatars do not belong to a post")
+		}
+		if ticket.Size > s.users.MaxAvatarSize() {
+			return nil, s.users.avatarTooLarge()
+		}
+	case model.UploadTicketAttachment:
+		if ticket.PostID == nil {
+			return nil, errors.New("invalid ticket: attachments need a post_id")
+		}
+		if ticket.Size > s.maxSize {
+			return nil, fmt.Errorf("invalid size: the file must be at most %d MB", s.maxSize/(1024*1024))
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			_, err := findEditablePostForUpdate(tx, *ticket.PostID, userID)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("invalid purpose: must be avatar or attachment")
+	}
+
+	ticket.UserID = userID
+	ticket.OriginalName = filepath.Base(ticket.OriginalName)
+	ticket.ObjectName = uuid.New().String() + filepath.Ext(ticket.OriginalName)
+	ticket.ExpiresAt = time.Now().Add(s.expiry)
+
+	uploadURL, err := s.storage.SignedUploadURL(ctx, storage.StagingKey(ticket.ObjectName), s.expiry)
+	if err != nil {
+		return nil, err
+	}
+	if err := ticket.Create(s.db); err != nil {
+		return nil, err
+	}
+	ticket.UploadURL = uploadURL
+	return ticket, nil
+}
+
+// CompleteTicket checks that the file of one of the user's tickets is in storage with the declared
+// size and checksum, then links it: an attachment is added to the post with the given alt text, to be
+// published once the upload queue has scanned it, and an avatar is processed like one sent with the
+// profile. The ticket is used up in the process. The upload link stays valid until it expires, so an
+// attachment is first moved to its held key, which no link can write to, and checked there.
+func (s *UploadTicketService) CompleteTicket(ctx context.Context, id, userID uuid.UUID, altText string) (*model.UploadTicket, error) {
+	ticket, err := new(model.UploadTicket).FindByID(s.db, id)
+	if err != nil || ticket.UserID != userID {
+		return nil, errors.New("upload ticket not found")
+	}
+	if time.Now().After(ticket.ExpiresAt.Add(uploadTicketGrace)) {
+		return nil, ErrUploadTicketExpired
+	}
+
+	if ticket.Purpose == model.UploadTicketAvatar {
+		// The avatar is processed from the very bytes that were checked
+		data, err := s.verifyUpload(ctx, ticket, storage.StagingKey(ticket.ObjectName))
+		if err != nil {
+			return nil, err
+		}
+		if ticket.User, err = s.users.UpdateAvatar(userID, data); err != nil {
+			return nil, err
+		}
+		// The avatar is stored in its processed sizes, so the upload itself is no longer needed
+		if err := ticket.Delete(s.db); err != nil {
+			slog.Error("Failed to delete completed upload ticket", "ticketID", ticket.ID, "error", err)
+		}
+		if err := s.storage.Delete(ctx, storage.StagingKey(ticket.ObjectName)); err != nil {
+			slog.Error("Error deleting uploaded avatar", "file", ticket.ObjectName, "error", err)
+		}
+		return ticket, nil
+	}
+
+	attachment := &model.PostAttachment{
+		FileName:     ticket.ObjectName,
+		OriginalName: ticket.OriginalName,
+		ContentType:  ticket.ContentType,
+		Size:         ticket.Size,
+		AltText:      altText,
+	}
+	heldKey := storage.HeldKey(ticket.ObjectName)
+	held := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Completing the ticket twice must not add the file twice
+		if _, err := new(model.UploadTicket).FindByIDForUpdate(tx, ticket.ID); err != nil {
+			return errors.New("upload ticket not found")
+		}
+		err := s.storage.Move(ctx, storage.StagingKey(ticket.ObjectName), heldKey)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return errNothingUploaded
+		}
+		if err != nil {
+			return err
+		}
+		held = true
+		if _, err := s.verifyUpload(ctx, ticket, heldKey); err != nil {
+			return err
+		}
+		if err := s.attachments.AddStoredAttachment(tx, *ticket.PostID, userID, attachment); err != nil {
+			return err
+		}
+		return ticket.Delete(tx)
+	})
+	if err != nil {
+		// The ticket is still there, so its file goes back to be uploaded again or cleaned up
+		if held {
+			if err := s.storage.Move(ctx, heldKey, storage.StagingKey(ticket.ObjectName)); err != nil {
+				slog.Error("Error moving uploaded file back to staging", "file", ticket.ObjectName, "error", err)
+			}
+		}
+		return nil, err
+	}
+	ticket.Attachment = attachment
+	return ticket, nil
+}
+
+// CleanUpExpired deletes tickets that were never completed, along with anything uploaded for them.
+func (s *UploadTicketService) CleanUpExpired(ctx context.Context) {
+	var found model.UploadTicket
+	expired, err := found.FindExpiredBefore(s.db.WithContext(ctx), time.Now().Add(-uploadTicketGrace), expiredTicketBatchSize)
+	if err != nil {
+		slog.Error("Failed to find expired upload tickets", "error", err)
+		return
+	}
+
+	cleaned := 0
+	for _, candidate := range expired {
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			// The ticket may have been completed since it was found
+			ticket, err := new(model.UploadTicket).FindByIDForUpdate(tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			return ticket.Delete(tx)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to delete expired upload ticket", "ticketID", candidate.ID, "error", err)
+			continue
+		}
+
+		// A failed completion may have left the file held
+		for _, key := range []string{storage.StagingKey(candidate.ObjectName), storage.HeldKey(candidate.ObjectName)} {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				slog.Error("Error deleting file of expired upload ticket", "file", key, "error", err)
+			}
+		}
+		cleaned++
+	}
+	if cleaned > 0 {
+		slog.Info("Cleaned up expired upload tickets", "count", cleaned)
+	}
+}
+
+// verifyUpload checks the ticket's file, staged under key, against the size and checksum declared on
+// the ticket, reading it back from storage to hash it. Avatars are small and processed next, so their
+// content is returned.
+func (s *UploadTicketService) verifyUpload(ctx context.Context, ticket *model.UploadTicket, key string) ([]byte, error) {
+	file, info, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, errNothingUploaded
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if info.Size >= 0 && info.Size != ticket.Size {
+		return nil, fmt.Errorf("invalid upload: the file is %d bytes, not the declared %d", info.Size, ticket.Size)
+	}
+
+	hasher := sha256.New()
+	var data []byte
+	if ticket.Purpose == model.UploadTicketAvatar {
+		data, err = io.ReadAll(io.TeeReader(io.LimitReader(file, ticket.Size+1), hasher))
+	} else {
+		_, err = io.Copy(hasher, io.LimitReader(file, ticket.Size+1))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != ticket.ChecksumSHA256 {
+		return nil, errors.New("invalid upload: the file does not match the declared checksum")
+	}
+	return data, nil
+}
@@ -2,13 +2,21 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"venturo-core/configs"
 	"venturo-core/internal/adapter/storage"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/imaging"
 	"venturo-core/pkg/uploader"
 
 	"log/slog"
@@ -17,34 +25,66 @@ import (
 	"gorm.io/gorm"
 )
 
-// Define the temporary local storage path
-const tempUploadPath = "./public/uploads/avatars"
+// Define the temporary local storage path, out of ./public so avatars are not served before they are scanned
+const tempUploadPath = "./uploads/avatars"
+
+// avatarSizes are the square sizes, in pixels, every avatar is stored in.
+var avatarSizes = []int{64, 256, 512}
 
 type UserService struct {
-	db       *gorm.DB
-	uploader *uploader.FileUploader
-	wg       *sync.WaitGroup
+	db                 *gorm.DB
+	storage            storage.StorageAdapter
+	uploader           *uploader.FileUploader
+	files              *StoredFileService
+	wg                 *sync.WaitGroup
+	maxAvatarSize      int64
+	maxAvatarDimension int
 }
 
-func NewUserService(db *gorm.DB, wg *sync.WaitGroup) *UserService {
-	gcsAdapter := storage.NewGCSAdapter("your-gcs-bucket-name")
-	fileUploader := uploader.NewFileUploader(gcsAdapter, tempUploadPath)
+func NewUserService(db *gorm.DB, conf *configs.Config, wg *sync.WaitGroup, storageAdapter storage.StorageAdapter, queue *UploadQueue, files *StoredFileService) *UserService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, tempUploadPath)
 
 	// Ensure the temporary upload directory exists
 	if err := os.MkdirAll(tempUploadPath, os.ModePerm); err != nil {
 		slog.Error("could not create temp upload directory", "error", err)
 		os.Exit(1)
 	}
-	return &UserService{db: db, uploader: fileUploader, wg: wg}
+
+	s := &UserService{
+		db:                 db,
+		storage:            storageAdapter,
+		uploader:           fileUploader,
+		files:              files,
+		wg:                 wg,
+		maxAvatarSize:      conf.AvatarMaxSize,
+		maxAvatarDimension: conf.AvatarMaxDimension,
+	}
+	files.OnStatusChange(s.followAvatarFile)
+	// Avatars stored before files were tracked are uploaded for the user instead
+	queue.Register(model.UploadTargetAvatar, UploadTarget{
+		Done:        s.completeAvatar,
+		Failed:      s.failAvatar,
+		Quarantined: s.quarantineAvatar,
+		Stalled:     s.stalledAvatars,
+	})
+	return s
 }
 
 // GetUserProfile retrieves a user's profile by their ID.
 func (s *UserService) GetUserProfile(userID uuid.UUID) (*model.User, error) {
-	var user model.User
-	return user.FindByID(s.db, userID)
+	var found model.User
+	user, err := found.FindByID(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.setAvatarURLs(user)
+	return user, nil
 }
 
-// UpdateUserProfile updates a user's profile data.
+// UpdateUserProfile updates a user's profile data. A new avatar is checked to be an image,
+// stripped of its metadata and resized to every avatar size before this returns; the sizes
+// are then moved to storage in the background by the upload queue, unless the same image
+// was uploaded before and is already stored.
 func (s *UserService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, newName string, file *multipart.FileHeader) (*model.User, error) {
 	// First, find the user to ensure they exist.
 	user, err := s.GetUserProfile(userID)
@@ -52,45 +92,265 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, n
 		return nil, err // User not found
 	}
 
+	var avatar []byte
 	if file != nil {
-		// Generate a new unique filename
-		ext := filepath.Ext(file.Filename)
-		newFileName := fmt.Sprintf("%s%s", uuid.New().String(), ext)
-		user.AvatarURL = newFileName // Store only the filename
-		user.ImageStatus = "uploading"
+		if avatar, err = s.readAvatar(file); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update the user's name.
 	user.Name = newName
 
-	// Save the updated user record. GORM's Save() handles updates automatically.
-	if err := user.Save(s.db); err != nil {
+	if err := s.saveUser(user, avatar); err != nil {
 		return nil, err
 	}
+	s.setAvatarURLs(user)
+	return user, nil
+}
 
-	if file != nil {
-		// Define the database logic in callbacks
-		onLocalUpload := func() {
-			user.ImageStatus = "local"
-			if err := user.Save(s.db); err != nil {
-				slog.Error("Error updating status to 'local' for user", "userID", userID, "error", err)
+// UpdateAvatar replaces the user's avatar with an image that has already been read,
+// processing it the same way as one sent with the profile.
+func (s *UserService) UpdateAvatar(userID uuid.UUID, data []byte) (*model.User, error) {
+	user, err := s.GetUserProfile(userID)
+	if err != nil {
+		return nil, err // User not found
+	}
+
+	if err := s.saveUser(user, data); err != nil {
+		return nil, err
+	}
+	s.setAvatarURLs(user)
+	return user, nil
+}
+
+// saveUser saves the user record, along with a new avatar when one is given. The replaced
+// avatar loses the user's reference; one stored before files were tracked is deleted outright.
+func (s *UserService) saveUser(user *model.User, avatar []byte) error {
+	var variants []imaging.Variant
+	if avatar != nil {
+		var err error
+		if variants, err = s.avatarVariants(avatar); err != nil {
+			return err
+		}
+	}
+
+	previousAvatar, previousObjects := user.AvatarURL, user.AvatarObjects()
+	untracked := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if variants != nil {
+			if err := s.setAvatar(tx, user, avatar, variants); err != nil {
+				return err
 			}
+			released, err := s.files.Release(tx, previousAvatar)
+			if err != nil {
+				return err
+			}
+			untracked = !released
 		}
+		return user.Save(tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	if untracked && len(previousObjects) > 0 {
+		s.deleteAvatar(previousObjects)
+	}
+	return nil
+}
 
-		onCloudUpload := func() {
-			user.ImageStatus = "cloud"
-			if err := user.Save(s.db); err != nil {
-				slog.Error("Error updating status to 'cloud' for user", "userID", userID, "error", err)
+// setAvatar points the user at the stored file of an avatar, storing its sizes first unless
+// the same image was uploaded before. Avatars are named after the hash of the uploaded image.
+// It must run inside a transaction.
+func (s *UserService) setAvatar(tx *gorm.DB, user *model.User, avatar []byte, variants []imaging.Variant) error {
+	sum := sha256.Sum256(avatar)
+	hash := hex.EncodeToString(sum[:])
+
+	sizes := make([]string, len(variants))
+	for i, variant := range variants {
+		sizes[i] = strconv.Itoa(variant.Size)
+	}
+	file, reused, err := s.files.Acquire(tx, &model.StoredFile{
+		SHA256:      hash,
+		ObjectName:  hash + variants[0].Ext,
+		Variants:    strings.Join(sizes, ","),
+		ContentType: variants[0].ContentType,
+		Size:        int64(len(avatar)),
+	})
+	if err != nil {
+		return err
+	}
+	if !reused {
+		objects := make([]StoredObject, len(variants))
+		for i, variant := range variants {
+			objects[i] = StoredObject{
+				Name:        model.AvatarVariantName(file.ObjectName, variant.Size),
+				Data:        variant.Data,
+				ContentType: variant.ContentType,
 			}
 		}
+		if err := s.files.Store(tx, file, objects); err != nil {
+			return err
+		}
+	}
 
-		// Start the background process
-		s.wg.Add(1)
-		go func() {
-			defer s.wg.Done()
-			s.uploader.UploadAsync(file, user.AvatarURL, onLocalUpload, onCloudUpload)
-		}()
+	user.AvatarURL = file.ObjectName // Store only the filename
+	user.AvatarVariants = file.Variants
+	user.ImageStatus = file.Status
+	return nil
+}
+
+// avatarVariants checks an avatar image and turns it into its stored sizes.
+func (s *UserService) avatarVariants(avatar []byte) ([]imaging.Variant, error) {
+	if int64(len(avatar)) > s.maxAvatarSize {
+		return nil, s.avatarTooLarge()
+	}
+	variants, err := imaging.SquareVariants(avatar, s.maxAvatarDimension, avatarSizes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid avatar: %w", err)
 	}
+	return variants, nil
+}
 
-	return user, nil
+// readAvatar reads an uploaded avatar, refusing files over the size limit.
+func (s *UserService) readAvatar(file *multipart.FileHeader) ([]byte, error) {
+	if file.Size > s.maxAvatarSize {
+		return nil, s.avatarTooLarge()
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// The declared size is not trusted either
+	data, err := io.ReadAll(io.LimitReader(src, s.maxAvatarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxAvatarSize {
+		return nil, s.avatarTooLarge()
+	}
+	return data, nil
+}
+
+// MaxAvatarSize returns the largest avatar file accepted, in bytes.
+func (s *UserService) MaxAvatarSize() int64 {
+	return s.maxAvatarSize
+}
+
+// avatarTooLarge is the error for avatar files over the size limit.
+func (s *UserService) avatarTooLarge() error {
+	return fmt.Errorf("invalid avatar: the file must be at most %d KB", s.maxAvatarSize/1024)
+}
+
+// setAvatarURLs fills in where each size of the user's avatar is served from. They are
+// left out while the avatar is still on its way to storage.
+func (s *UserService) setAvatarURLs(user *model.User) {
+	if user.ImageStatus != "cloud" || user.AvatarURL == "" {
+		return
+	}
+
+	sizes := user.AvatarSizes()
+	if len(sizes) == 0 {
+		user.AvatarURLs = map[string]string{"original": s.storage.URL(user.AvatarURL)}
+		return
+	}
+	user.AvatarURLs = make(map[string]string, len(sizes))
+	for _, size := range sizes {
+		user.AvatarURLs[strconv.Itoa(size)] = s.storage.URL(model.AvatarVariantName(user.AvatarURL, size))
+	}
+}
+
+// deleteAvatar removes the stored files of a replaced avatar in the background.
+func (s *UserService) deleteAvatar(objectNames []string) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for _, objectName := range objectNames {
+			if err := s.uploader.Delete(context.Background(), objectName); err != nil {
+				slog.Error("Error deleting replaced avatar", "file", objectName, "error", err)
+			}
+		}
+	}()
+}
+
+// followAvatarFile passes the status of a stored avatar on to every user showing it.
+func (s *UserService) followAvatarFile(tx *gorm.DB, file *model.StoredFile) error {
+	var user model.User
+	return user.UpdateImageStatusByAvatar(tx, file.ObjectName, file.Status)
+}
+
+// completeAvatar marks the avatar as stored once the last of its sizes is.
+func (s *UserService) completeAvatar(tx *gorm.DB, job *model.UploadJob) error {
+	var found model.User
+	user, err := found.FindByID(tx, job.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Uploads of a replaced avatar, or of one that already failed or was quarantined, change nothing
+	objects := user.AvatarObjects()
+	if user.ImageStatus == "failed" || user.ImageStatus == "quarantined" || !slices.Contains(objects, job.ObjectName) {
+		return nil
+	}
+
+	open, err := job.CountOpen(tx, user.ID, objects)
+	if err != nil || open > 0 {
+		return err
+	}
+	return user.UpdateImageStatus(tx, user.AvatarURL, "cloud")
+}
+
+// failAvatar marks the avatar as failed when any of its sizes cannot be stored.
+func (s *UserService) failAvatar(tx *gorm.DB, job *model.UploadJob) error {
+	return s.stopAvatar(tx, job, "failed")
+}
+
+// quarantineAvatar marks the avatar as quarantined when any of its sizes is infected.
+func (s *UserService) quarantineAvatar(tx *gorm.DB, job *model.UploadJob) error {
+	return s.stopAvatar(tx, job, "quarantined")
+}
+
+// stopAvatar records why the upload of the avatar the job belongs to ended without storing it.
+func (s *UserService) stopAvatar(tx *gorm.DB, job *model.UploadJob, status string) error {
+	var found model.User
+	user, err := found.FindByID(tx, job.TargetID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(user.AvatarObjects(), job.ObjectName) {
+		return nil
+	}
+	return user.UpdateImageStatus(tx, user.AvatarURL, status)
+}
+
+// stalledAvatars lists the avatar uploads the upload queue lost track of.
+func (s *UserService) stalledAvatars(db *gorm.DB) ([]model.UploadJob, error) {
+	var found model.User
+	users, err := found.FindStalledAvatars(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []model.UploadJob
+	for _, user := range users {
+		for _, objectName := range user.AvatarObjects() {
+			jobs = append(jobs, model.UploadJob{
+				TargetID:   user.ID,
+				ObjectName: objectName,
+				LocalPath:  s.uploader.LocalPath(objectName),
+			})
+		}
+	}
+	return jobs, nil
 }
@@ -0,0 +1,43 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalid is returned when a cursor was not produced by Encode.
+var ErrInvalid = errors.New("invalid cursor")
+
+// Encode builds an opaque cursor pointing at a row ordered by (timestamp, id).
+func Encode(at time.Time, id uuid.UUID) string {
+	raw := strconv.FormatInt(at.UnixNano(), 10) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode reads back the timestamp and id of a cursor made by Encode.
+func Decode(value string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalid
+	}
+
+	nanos, idPart, found := strings.Cut(string(raw), "|")
+	if !found {
+		return time.Time{}, uuid.Nil, ErrInvalid
+	}
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalid
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalid
+	}
+
+	return time.Unix(0, unixNano), id, nil
+}
@@ -0,0 +1,112 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// ErrUnsupportedFormat is returned for content that is not a JPEG, PNG, GIF or WebP image.
+var ErrUnsupportedFormat = errors.New("unsupported image format, expected JPEG, PNG, GIF or WebP")
+
+// jpegQuality is the quality JPEG variants are encoded at.
+const jpegQuality = 85
+
+// decoders maps the sniffed content types to their decoders.
+var decoders = map[string]struct {
+	decode       func(r *bytes.Reader) (image.Image, error)
+	decodeConfig func(r *bytes.Reader) (image.Config, error)
+}{
+	"image/jpeg": {func(r *bytes.Reader) (image.Image, error) { return jpeg.Decode(r) }, func(r *bytes.Reader) (image.Config, error) { return jpeg.DecodeConfig(r) }},
+	"image/png":  {func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) }, func(r *bytes.Reader) (image.Config, error) { return png.DecodeConfig(r) }},
+	"image/gif":  {func(r *bytes.Reader) (image.Image, error) { return gif.Decode(r) }, func(r *bytes.Reader) (image.Config, error) { return gif.DecodeConfig(r) }},
+	"image/webp": {func(r *bytes.Reader) (image.Image, error) { return webp.Decode(r) }, func(r *bytes.Reader) (image.Config, error) { return webp.DecodeConfig(r) }},
+}
+
+// Variant is one encoded size of an image.
+type Variant struct {
+	Size        int // The requested width and height; images are never enlarged to reach it
+	Data        []byte
+	ContentType string
+	Ext         string
+}
+
+// Sniff detects the content type of an image from its leading bytes, whatever its
+// file name or declared type claim.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := decoders[contentType]; !ok {
+		return "", ErrUnsupportedFormat
+	}
+	return contentType, nil
+}
+
+// SquareVariants crops an image to a centred square and encodes it once per size. Images
+// wider or taller than maxDimension are rejected before they are decoded. The variants are
+// re-encoded from pixels only, so no metadata such as EXIF or GPS data is carried over; the
+// EXIF orientation of JPEG photos is applied first. Variants of sizes larger than the image
+// keep its own size. JPEG images stay JPEG; other formats become PNG to keep transparency.
+func SquareVariants(data []byte, maxDimension int, sizes []int) ([]Variant, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := decoders[contentType]
+
+	config, err := decoder.decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return nil, fmt.Errorf("image is %dx%d pixels, at most %dx%d are allowed", config.Width, config.Height, maxDimension, maxDimension)
+	}
+
+	img, err := decoder.decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	// Centre the largest square that fits
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(bounds.Min).
+		Add(image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2))
+
+	variants := make([]Variant, 0, len(sizes))
+	for _, size := range sizes {
+		pixels := min(size, side)
+		scaled := image.NewRGBA(image.Rect(0, 0, pixels, pixels))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, crop, draw.Src, nil)
+		// Turning a centred square yields the same square, so the orientation can be applied after scaling
+		oriented := orient(scaled, orientation)
+
+		variant := Variant{Size: size}
+		var buf bytes.Buffer
+		if contentType == "image/jpeg" {
+			variant.ContentType, variant.Ext = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&buf, oriented, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			variant.ContentType, variant.Ext = "image/png", ".png"
+			err = png.Encode(&buf, oriented)
+		}
+		if err != nil {
+			return nil, err
+		}
+		variant.Data = buf.Bytes()
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
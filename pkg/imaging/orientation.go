@@ -0,0 +1,111 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// orientationTag is the EXIF tag holding how the camera was held.
+const orientationTag = 0x0112
+
+// jpegOrientation reads the EXIF orientation of a JPEG image. It returns 1, meaning
+// upright, when the image has none or its metadata cannot be read.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the marker segments up to the image data, looking for the EXIF segment (APP1)
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA { // Start of scan: no metadata follows
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first directory of TIFF-encoded EXIF data.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	dir := int(order.Uint32(tiff[4:]))
+	if dir < 0 || dir+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[dir:]))
+	for i := 0; i < entries; i++ {
+		entry := dir + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// orient turns a square image upright according to its EXIF orientation.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation == 1 {
+		return img
+	}
+
+	size := img.Bounds().Dx()
+	last := size - 1
+	out := image.NewRGBA(img.Bounds())
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Flip horizontally
+				dx, dy = last-x, y
+			case 3: // Rotate half a turn
+				dx, dy = last-x, last-y
+			case 4: // Flip vertically
+				dx, dy = x, last-y
+			case 5: // Transpose
+				dx, dy = y, x
+			case 6: // Rotate a quarter clockwise
+				dx, dy = last-y, x
+			case 7: // Transverse
+				dx, dy = last-y, last-x
+			case 8: // Rotate a quarter anticlockwise
+				dx, dy = y, last-x
+			default:
+				return img
+			}
+			out.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return out
+}
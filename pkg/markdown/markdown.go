@@ -0,0 +1,68 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+)
+
+// wordsPerMinute is the average reading speed used for reading time estimates.
+const wordsPerMinute = 200
+
+var (
+	// goldmark follows CommonMark and drops raw HTML from the source by default
+	renderer = goldmark.New()
+
+	// htmlPolicy allows the formatting markdown produces and strips scripts, handlers and unsafe URLs
+	htmlPolicy = bluemonday.UGCPolicy()
+
+	// textPolicy removes every tag, leaving only text content
+	textPolicy = bluemonday.StrictPolicy()
+)
+
+// ToHTML renders CommonMark source to sanitized HTML that is safe to embed in a page.
+func ToHTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
+
+// ToPlainText strips rendered HTML down to its text, keeping one line per block.
+func ToPlainText(renderedHTML string) string {
+	text := html.UnescapeString(textPolicy.Sanitize(renderedHTML))
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ReadingTime estimates the minutes needed to read the text, rounded up.
+func ReadingTime(plainText string) int {
+	words := len(strings.Fields(plainText))
+	return int(math.Ceil(float64(words) / wordsPerMinute))
+}
+
+// Excerpt shortens the text to at most maxRunes characters, cutting at a word boundary.
+func Excerpt(plainText string, maxRunes int) string {
+	text := strings.Join(strings.Fields(plainText), " ")
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+
+	cut := string(runes[:maxRunes])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:!?") + "…"
+}
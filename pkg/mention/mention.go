@@ -0,0 +1,42 @@
+package mention
+
+import (
+	"regexp"
+	"strings"
+)
+
+// usernamePattern is the shape of a valid username: 3 to 30 letters, digits or underscores.
+var usernamePattern = regexp.MustCompile(`^\w{3,30}$`)
+
+// mentionPattern finds @username mentions. The character before the @ must not be part
+// of a word, so e-mail addresses such as jane@example.com are not taken for mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@/])@(\w{3,30})\b`)
+
+// ValidUsername reports whether s can be used as a username.
+func ValidUsername(s string) bool {
+	return usernamePattern.MatchString(s)
+}
+
+// Normalize returns the canonical, lowercase form of a username.
+func Normalize(username string) string {
+	return strings.ToLower(username)
+}
+
+// Extract returns the normalized usernames mentioned in text, in order of first appearance
+// and without duplicates. At most limit usernames are returned.
+func Extract(text string, limit int) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := Normalize(match[1])
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == limit {
+			break
+		}
+	}
+	return usernames
+}
@@ -0,0 +1,88 @@
+package pubsub
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// subscriberBuffer is how many undelivered messages a subscriber may fall behind by.
+const subscriberBuffer = 16
+
+// Hub fans messages out to the subscribers of a topic within this process.
+type Hub[K comparable, T any] struct {
+	mu          sync.Mutex
+	subscribers map[K]map[chan T]struct{}
+	closed      bool
+}
+
+// NewHub creates an empty hub.
+func NewHub[K comparable, T any]() *Hub[K, T] {
+	return &Hub[K, T]{subscribers: make(map[K]map[chan T]struct{})}
+}
+
+// Subscribe registers a new subscriber to the topic. The returned channel is closed
+// once unsubscribe is called or the hub is closed.
+func (h *Hub[K, T]) Subscribe(topic K) (<-chan T, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan T, subscriberBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[chan T]struct{})
+	}
+	h.subscribers[topic][ch] = struct{}{}
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subscribers[topic][ch]; !ok {
+				return // Already closed by Close
+			}
+			delete(h.subscribers[topic], ch)
+			if len(h.subscribers[topic]) == 0 {
+				delete(h.subscribers, topic)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish delivers the message to every current subscriber of the topic without blocking.
+// Subscribers that have fallen too far behind miss the message.
+func (h *Hub[K, T]) Publish(topic K, message T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[topic] {
+		select {
+		case ch <- message:
+		default:
+			slog.Warn("Dropping message for slow subscriber", "topic", topic)
+		}
+	}
+}
+
+// Close ends every subscription. Later subscriptions are closed immediately.
+func (h *Hub[K, T]) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for topic, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(h.subscribers, topic)
+	}
+}
@@ -11,6 +11,7 @@ type ApiResponse struct {
 	StatusCode int         `json:"status_code"`
 	Data       interface{} `json:"data,omitempty"`
 	Meta       *Meta       `json:"meta,omitempty"`
+	Cursor     *CursorMeta `json:"cursor,omitempty"`
 	Errors     interface{} `json:"errors,omitempty"`
 }
 
@@ -22,6 +23,12 @@ type Meta struct {
 	TotalPages   int   `json:"total_pages"`
 }
 
+// CursorMeta holds the metadata of a cursor-paginated response.
+type CursorMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
 // Success sends a standard success response.
 func Success(c *fiber.Ctx, statusCode int, data interface{}) error {
 	return c.Status(statusCode).JSON(ApiResponse{
@@ -45,6 +52,15 @@ func Pagination(c *fiber.Ctx, data interface{}, page, limit int, total int64) er
 	})
 }
 
+// CursorPagination sends a page of a cursor-paginated listing. An empty nextCursor marks the last page.
+func CursorPagination(c *fiber.Ctx, data interface{}, nextCursor string) error {
+	return c.Status(fiber.StatusOK).JSON(ApiResponse{
+		StatusCode: fiber.StatusOK,
+		Data:       data,
+		Cursor:     &CursorMeta{NextCursor: nextCursor, HasMore: nextCursor != ""},
+	})
+}
+
 // Error sends a standard error response.
 func Error(c *fiber.Ctx, statusCode int, err error) error {
 	return c.Status(statusCode).JSON(ApiResponse{
@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RunEvery runs job in the background on a fixed interval until ctx is cancelled.
+// The goroutine is tracked by wg so graceful shutdown waits for a running job to finish.
+// An interval of zero or less turns the job off.
+func RunEvery(ctx context.Context, wg *sync.WaitGroup, name string, interval time.Duration, job func(ctx context.Context)) {
+	if interval <= 0 {
+		slog.Info("Background job disabled", "job", name, "interval", interval.String())
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		slog.Info("Background job scheduled", "job", name, "interval", interval.String())
+		for {
+			select {
+			case <-ctx.Done():
+				slog.Info("Background job stopped", "job", name)
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	}()
+}
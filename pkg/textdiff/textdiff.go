@@ -0,0 +1,116 @@
+package textdiff
+
+import "strings"
+
+// Operation kinds reported for each line of a diff.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxTableCells caps the size of the LCS table, which takes four bytes a cell. Past it, the
+// changed middle of the texts is reported as deleted and inserted whole instead.
+const maxTableCells = 4 << 20
+
+// Line is a single line of a line-based diff.
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines computes a line-based diff that turns a into b.
+func Lines(a, b string) []Line {
+	return diff(splitLines(a), splitLines(b))
+}
+
+// splitLines splits text into lines, treating "\r\n" like "\n".
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(text, "\n")
+}
+
+// diff runs a longest-common-subsequence diff over the two line slices.
+func diff(a, b []string) []Line {
+	// 1. Strip the common prefix and suffix, which keeps the table small for typical edits
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	result := make([]Line, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		result = append(result, Line{Op: OpEqual, Text: text})
+	}
+
+	// 2. Compare the remaining middle section, unless its table would be too large
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if int64(len(midA)+1)*int64(len(midB)+1) > maxTableCells {
+		for _, text := range midA {
+			result = append(result, Line{Op: OpDelete, Text: text})
+		}
+		for _, text := range midB {
+			result = append(result, Line{Op: OpInsert, Text: text})
+		}
+	} else {
+		result = lcs(result, midA, midB)
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		result = append(result, Line{Op: OpEqual, Text: text})
+	}
+	return result
+}
+
+// lcs appends a longest-common-subsequence diff that turns midA into midB to result.
+func lcs(result []Line, midA, midB []string) []Line {
+	// Build the LCS length table
+	n, m := len(midA), len(midB)
+	table := make([][]int32, n+1)
+	for i := range table {
+		table[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	// Walk the table to emit the edit script
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case midA[i] == midB[j]:
+			result = append(result, Line{Op: OpEqual, Text: midA[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			result = append(result, Line{Op: OpDelete, Text: midA[i]})
+			i++
+		default:
+			result = append(result, Line{Op: OpInsert, Text: midB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, Line{Op: OpDelete, Text: midA[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, Line{Op: OpInsert, Text: midB[j]})
+	}
+	return result
+}
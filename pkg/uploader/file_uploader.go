@@ -26,58 +26,52 @@ func NewFileUploader(storageAdapter storage.StorageAdapter, localPath string) *F
 	return &FileUploader{storageAdapter: storageAdapter, localPath: localPath}
 }
 
-// UploadAsync handles the entire file processing pipeline in the background.
-func (u *FileUploader) UploadAsync(
-	file *multipart.FileHeader,
-	objectName string,
-	onLocalUploadSuccess func(),
-	onCloudUploadSuccess func(),
-) {
-	// --- Step 1: Save Locally ---
-	localFilePath := filepath.Join(u.localPath, objectName)
-	if err := saveToLocal(file, localFilePath); err != nil {
-		slog.Error("Error saving temp file", "file", objectName, "error", err)
-		return
+// SaveLocal keeps a temp copy of a file until it is moved to storage,
+// and returns where the copy was saved.
+func (u *FileUploader) SaveLocal(content io.Reader, objectName string) (string, error) {
+	localFilePath := u.LocalPath(objectName)
+	if err := saveToLocal(content, localFilePath); err != nil {
+		return "", err
 	}
 	slog.Info("Successfully saved temp file", "path", localFilePath)
+	return localFilePath, nil
+}
 
-	// Execute the first callback
-	onLocalUploadSuccess()
+// LocalPath returns where the temp copy of a file is kept.
+func (u *FileUploader) LocalPath(objectName string) string {
+	return filepath.Join(u.localPath, objectName)
+}
 
-	// --- Step 2: Upload to Cloud ---
-	// In a real app, you would read the local file's contents to upload.
-	// We pass the header for placeholder simplicity.
-	if _, err := u.storageAdapter.Upload(context.Background(), file); err != nil {
-		slog.Error("Error uploading to cloud", "file", objectName, "error", err)
-		return // Don't continue if cloud upload fails
+// Delete removes a file from cloud storage, along with its temp copy if the upload never finished.
+func (u *FileUploader) Delete(ctx context.Context, objectName string) error {
+	if err := os.Remove(u.LocalPath(objectName)); err != nil && !os.IsNotExist(err) {
+		return err
 	}
-	slog.Info("Successfully uploaded to cloud", "file", objectName)
 
-	// Execute the second callback
-	onCloudUploadSuccess()
-
-	// --- Step 3: Cleanup ---
-	if err := os.Remove(localFilePath); err != nil {
-		slog.Error("Error cleaning up temp file", "file", objectName, "error", err)
-	} else {
-		slog.Info("Successfully cleaned up temp file", "file", objectName)
-	}
+	return u.storageAdapter.Delete(ctx, objectName)
 }
 
-// saveToLocal is a helper function containing the file-saving logic.
-func saveToLocal(file *multipart.FileHeader, path string) error {
+// SaveLocalFile keeps a temp copy of an uploaded file until it is moved to storage.
+func (u *FileUploader) SaveLocalFile(file *multipart.FileHeader, objectName string) (string, error) {
 	src, err := file.Open()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer src.Close()
 
+	return u.SaveLocal(src, objectName)
+}
+
+// saveToLocal is a helper function containing the file-saving logic.
+func saveToLocal(content io.Reader, path string) error {
 	dst, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	defer dst.Close()
 
-	_, err = io.Copy(dst, src)
-	return err
+	if _, err := io.Copy(dst, content); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
 }
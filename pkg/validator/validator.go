@@ -2,12 +2,22 @@ package validator
 
 import (
 	"strings"
+	"venturo-core/pkg/mention"
 
 	"github.com/go-playground/validator/v10"
 )
 
 // Global validator instance
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator creates the validator with the application's custom tags registered.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	_ = v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return mention.ValidUsername(fl.Field().String())
+	})
+	return v
+}
 
 // ValidateStruct performs validation on a struct using its 'validate' tags.
 func ValidateStruct(payload interface{}) map[string]string {
@@ -39,6 +49,10 @@ func ValidateStruct(payload interface{}) map[string]string {
 			errorMessages[fieldName] = fieldName + " must be at least " + fieldErr.Param() + " characters long"
 		case "max":
 			errorMessages[fieldName] = fieldName + " must be at most " + fieldErr.Param() + " characters long"
+		case "username":
+			errorMessages[fieldName] = fieldName + " must be 3 to 30 letters, digits or underscores"
+		case "oneof":
+			errorMessages[fieldName] = fieldName + " must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
 		default:
 			errorMessages[fieldName] = "invalid value"
 		}